@@ -1,8 +1,13 @@
 package gow
 
 import (
+	"github.com/gkzy/gow/i18n"
 	"github.com/gkzy/gow/lib/config"
+	"github.com/gkzy/gow/session"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 const (
@@ -30,6 +35,23 @@ type AppConfig struct {
 	Views         string //html 模板目录
 	TemplateLeft  string //html模板左符号
 	TemplateRight string //html模板右符号
+
+	ReadTimeout       time.Duration //读取请求的超时时间，配置文件中单位为秒
+	ReadHeaderTimeout time.Duration //读取请求头的超时时间，配置文件中单位为秒
+	WriteTimeout      time.Duration //写入响应的超时时间，配置文件中单位为秒
+	IdleTimeout       time.Duration //keep-alive 空闲时间，配置文件中单位为秒
+	MaxHeaderBytes    int           //请求头的最大字节数
+	MaxBodySize       int64         //请求体的最大字节数，为0时不限制
+
+	H2COn bool //是否开启 h2c (HTTP/2 cleartext)
+
+	SecretKeys     []string      //签名 cookie 的 key，配置文件中以逗号分隔
+	CookieSameSite http.SameSite //cookie 默认的 SameSite，配置文件中为 lax strict none
+	TrustedProxies []string      //可信代理的 CIDR 或 IP，配置文件中以逗号分隔
+
+	LocaleDir   string //语言包目录，如 conf/locale，为空时不加载
+	DefaultLang string //默认语言，如 zh-CN
+	LangParam   string //切换语言的 query 参数和 cookie
 }
 
 // GetAppConfig 获取配置文件中的信息
@@ -65,5 +87,38 @@ func GetAppConfig() *AppConfig {
 		Views:         config.DefaultString("views", "views"),
 		TemplateLeft:  config.DefaultString("template_left", "<<"),
 		TemplateRight: config.DefaultString("template_right", ">>"),
+
+		ReadTimeout:       defaultSeconds("read_timeout"),
+		ReadHeaderTimeout: defaultSeconds("read_header_timeout"),
+		WriteTimeout:      defaultSeconds("write_timeout"),
+		IdleTimeout:       defaultSeconds("idle_timeout"),
+		MaxHeaderBytes:    config.DefaultInt("max_header_bytes", 0),
+		MaxBodySize:       config.DefaultInt64("max_body_size", 0),
+
+		H2COn: config.DefaultBool("h2c_on", false),
+
+		SecretKeys:     splitConfig("secret_keys"),
+		CookieSameSite: session.ParseSameSite(config.GetString("cookie_same_site")),
+		TrustedProxies: splitConfig("trusted_proxies"),
+
+		LocaleDir:   config.GetString("locale_dir"),
+		DefaultLang: config.DefaultString("default_lang", i18n.DefaultLang),
+		LangParam:   config.DefaultString("lang_param", defaultLangParam),
+	}
+}
+
+// defaultSeconds 读取以秒为单位的配置，未配置时返回0
+func defaultSeconds(key string) time.Duration {
+	return time.Duration(config.DefaultInt64(key, 0)) * time.Second
+}
+
+// splitConfig 读取以逗号分隔的配置
+func splitConfig(key string) []string {
+	var ret []string
+	for _, v := range strings.Split(config.GetString(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ret = append(ret, v)
+		}
 	}
+	return ret
 }
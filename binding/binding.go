@@ -0,0 +1,96 @@
+// Package binding 把请求中的 query form json xml header 和路由参数绑定到结构体
+//	type UserRequest struct {
+//		ID     int64                 `uri:"id"`
+//		Token  string                `header:"X-Token"`
+//		Page   int                   `query:"page" default:"1"`
+//		Name   string                `form:"name" json:"name" xml:"name"`
+//		Tags   []string              `form:"tags" json:"tags"`
+//		Birth  time.Time             `form:"birth" time_format:"2006-01-02"`
+//		Avatar *multipart.FileHeader `form:"avatar"`
+//	}
+package binding
+
+import (
+	"github.com/gkzy/gow/validate"
+	"net/http"
+	"strings"
+)
+
+// Content-Type
+const (
+	MIMEJSON              = "application/json"
+	MIMEXML               = "application/xml"
+	MIMEXML2              = "text/xml"
+	MIMEPOSTForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartPOSTForm = "multipart/form-data"
+)
+
+// defaultMemory multipart 表单使用的最大内存
+const defaultMemory = 32 << 20
+
+// Binding 从请求中读取数据，绑定到 obj
+//	obj 必须是结构体指针
+type Binding interface {
+	Name() string
+	Bind(req *http.Request, obj interface{}) error
+}
+
+// URIBinding 绑定路由参数
+type URIBinding interface {
+	Name() string
+	BindURI(params map[string][]string, obj interface{}) error
+}
+
+// StructValidator 绑定后校验结构体
+type StructValidator interface {
+	ValidateStruct(obj interface{}) error
+}
+
+// Validator 绑定后使用的校验器，默认为 validate.Default，为 nil 时不校验
+var Validator StructValidator = validate.Default
+
+var (
+	JSON          = jsonBinding{}
+	XML           = xmlBinding{}
+	Form          = formBinding{}
+	FormPost      = formPostBinding{}
+	FormMultipart = formMultipartBinding{}
+	Query         = queryBinding{}
+	Header        = headerBinding{}
+	URI           = uriBinding{}
+)
+
+// Default 根据 Method 和 Content-Type 返回 body 的 binding
+//	GET 请求没有 body，返回 Form
+func Default(method, contentType string) Binding {
+	if method == http.MethodGet {
+		return Form
+	}
+
+	switch filterFlags(contentType) {
+	case MIMEJSON:
+		return JSON
+	case MIMEXML, MIMEXML2:
+		return XML
+	case MIMEMultipartPOSTForm:
+		return FormMultipart
+	default:
+		return Form
+	}
+}
+
+// Validate 使用 Validator 校验 obj
+func Validate(obj interface{}) error {
+	if Validator == nil {
+		return nil
+	}
+	return Validator.ValidateStruct(obj)
+}
+
+// filterFlags 去掉 Content-Type 中的参数，如 charset
+func filterFlags(content string) string {
+	if i := strings.IndexAny(content, "; "); i >= 0 {
+		content = content[:i]
+	}
+	return strings.ToLower(content)
+}
@@ -0,0 +1,128 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Page struct {
+	Page int `query:"page" default:"1"`
+	Size int `query:"size" default:"20"`
+}
+
+type Address struct {
+	City string `form:"city" json:"city"`
+}
+
+type userRequest struct {
+	Page
+	ID      int64                 `uri:"id"`
+	Token   string                `header:"X-Token"`
+	Name    string                `form:"name" json:"name"`
+	Tags    []string              `form:"tags" json:"tags"`
+	Sort    []string              `query:"sort" default:"id,name"`
+	Birth   time.Time             `form:"birth" time_format:"2006-01-02" json:"-"`
+	Age     *int                  `form:"age" json:"age"`
+	Address *Address              `json:"address"`
+	Avatar  *multipart.FileHeader `form:"avatar" json:"-"`
+}
+
+func TestMapping_Form(t *testing.T) {
+	body := "name=gow&tags=a&tags=b&birth=2020-05-01&age=18&city=sz"
+	req := httptest.NewRequest("POST", "/user?page=2&sort=age", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	req.Header.Set("x-token", "abc")
+
+	var u userRequest
+	if err := SetDefaults(&u); err != nil {
+		t.Fatal(err)
+	}
+	if err := URI.BindURI(map[string][]string{"id": {"10"}}, &u); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []Binding{Header, Query, Default(req.Method, req.Header.Get("Content-Type"))} {
+		if err := b.Bind(req, &u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if u.ID != 10 || u.Token != "abc" || u.Page.Page != 2 || u.Size != 20 || u.Name != "gow" {
+		t.Fatalf("unexpected result: %+v", u)
+	}
+	if len(u.Tags) != 2 || u.Tags[1] != "b" || len(u.Sort) != 1 || u.Sort[0] != "age" {
+		t.Fatalf("unexpected slices: %v %v", u.Tags, u.Sort)
+	}
+	if u.Birth.Format("2006-01-02") != "2020-05-01" || u.Age == nil || *u.Age != 18 {
+		t.Fatalf("unexpected time or pointer: %v %v", u.Birth, u.Age)
+	}
+	if u.Address == nil || u.Address.City != "sz" {
+		t.Fatalf("unexpected nested struct: %+v", u.Address)
+	}
+}
+
+func TestMapping_JSON(t *testing.T) {
+	req := httptest.NewRequest("POST", "/user", strings.NewReader(`{"name":"gow","address":{"city":"sz"}}`))
+	req.Header.Set("Content-Type", MIMEJSON+"; charset=utf-8")
+
+	var u userRequest
+	SetDefaults(&u)
+	if err := Default(req.Method, req.Header.Get("Content-Type")).Bind(req, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "gow" || u.Address.City != "sz" || u.Page.Page != 1 {
+		t.Fatalf("unexpected result: %+v", u)
+	}
+
+	req = httptest.NewRequest("POST", "/user", strings.NewReader(`{"age":"x"}`))
+	var fe *FieldError
+	if err := JSON.Bind(req, &u); !errors.As(err, &fe) || fe.Name != "age" {
+		t.Fatalf("want FieldError for age, got %v", err)
+	}
+}
+
+func TestMapping_Multipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("name", "gow")
+	fw, _ := mw.CreateFormFile("avatar", "a.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/user", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var u userRequest
+	if err := Default(req.Method, req.Header.Get("Content-Type")).Bind(req, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "gow" || u.Avatar == nil || u.Avatar.Filename != "a.png" {
+		t.Fatalf("unexpected result: %+v", u)
+	}
+}
+
+func TestMapping_FieldError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/user?page=abc", nil)
+	var u userRequest
+	err := Query.Bind(req, &u)
+
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		t.Fatalf("want *FieldError, got %v", err)
+	}
+	if fe.Field != "Page" || fe.Name != "page" || fe.Source != "query" || fe.Value != "abc" {
+		t.Fatalf("unexpected FieldError: %+v", fe)
+	}
+
+	if err = Query.Bind(req, u); err != ErrInvalidObj {
+		t.Fatalf("want ErrInvalidObj, got %v", err)
+	}
+	if Default(http.MethodGet, MIMEJSON) != Form {
+		t.Fatal("GET should use Form")
+	}
+}
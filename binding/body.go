@@ -0,0 +1,50 @@
+package binding
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type (
+	jsonBinding struct{}
+	xmlBinding  struct{}
+)
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+// Bind 解析 json body，body 为空时不处理
+func (jsonBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return nil
+	}
+	err := json.NewDecoder(req.Body).Decode(obj)
+	if err == io.EOF {
+		return nil
+	}
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		return &FieldError{Field: te.Field, Name: te.Field, Source: "json", Value: te.Value, Err: err}
+	}
+	return err
+}
+
+func (xmlBinding) Name() string {
+	return "xml"
+}
+
+// Bind 解析 xml body，body 为空时不处理
+func (xmlBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return nil
+	}
+	err := xml.NewDecoder(req.Body).Decode(obj)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidObj obj 不是结构体指针
+var ErrInvalidObj = errors.New("binding: obj must be a non-nil pointer to struct")
+
+// FieldError 绑定字段失败
+//		var fe *binding.FieldError
+//		if errors.As(err, &fe) {
+//			c.JSON(gow.H{"field": fe.Name, "msg": fe.Error()})
+//		}
+type FieldError struct {
+	Field  string // 结构体中的字段，嵌套时为 Parent.Field
+	Name   string // 请求中的名称
+	Source string // query form json xml header uri default
+	Value  string // 请求中的值
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("binding: field %s (%s %q) invalid value %q: %v", e.Field, e.Source, e.Name, e.Value, e.Err)
+}
+
+// Unwrap Unwrap
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
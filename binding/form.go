@@ -0,0 +1,98 @@
+package binding
+
+import (
+	"net/http"
+	"reflect"
+)
+
+type (
+	formBinding          struct{}
+	formPostBinding      struct{}
+	formMultipartBinding struct{}
+	queryBinding         struct{}
+	headerBinding        struct{}
+	uriBinding           struct{}
+)
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+// Bind 绑定 query 和 body 中的表单，multipart 表单同时绑定文件
+func (formBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	if err := req.ParseMultipartForm(defaultMemory); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+	if req.MultipartForm != nil {
+		return mapping(obj, "form", req.Form, req.MultipartForm.File)
+	}
+	return mapping(obj, "form", req.Form, nil)
+}
+
+func (formPostBinding) Name() string {
+	return "form-urlencoded"
+}
+
+// Bind 只绑定 body 中的表单
+func (formPostBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	return mapping(obj, "form", req.PostForm, nil)
+}
+
+func (formMultipartBinding) Name() string {
+	return "multipart/form-data"
+}
+
+// Bind 绑定 multipart 表单和文件
+func (formMultipartBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseMultipartForm(defaultMemory); err != nil {
+		return err
+	}
+	return mapping(obj, "form", req.MultipartForm.Value, req.MultipartForm.File)
+}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+// Bind 使用 query tag 绑定 url 中的参数
+func (queryBinding) Bind(req *http.Request, obj interface{}) error {
+	return mapping(obj, "query", req.URL.Query(), nil)
+}
+
+func (headerBinding) Name() string {
+	return "header"
+}
+
+// Bind 使用 header tag 绑定请求头
+func (headerBinding) Bind(req *http.Request, obj interface{}) error {
+	v, err := structValue(obj)
+	if err != nil {
+		return err
+	}
+	// 请求头不区分大小写，使用 tag 中的名称查找
+	values := make(map[string][]string)
+	walk(v, "", func(field reflect.StructField, value reflect.Value, path string) (bool, error) {
+		if name := tagName(field, "header"); name != "" {
+			if vals := req.Header.Values(name); len(vals) > 0 {
+				values[name] = vals
+			}
+		}
+		return false, nil
+	})
+	return mapping(obj, "header", values, nil)
+}
+
+func (uriBinding) Name() string {
+	return "uri"
+}
+
+// BindURI 使用 uri tag 绑定路由参数
+func (uriBinding) BindURI(params map[string][]string, obj interface{}) error {
+	return mapping(obj, "uri", params, nil)
+}
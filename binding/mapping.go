@@ -0,0 +1,352 @@
+package binding
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	durationType    = reflect.TypeOf(time.Duration(0))
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	unmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// MapForm 使用 form tag 把 values 绑定到 obj
+func MapForm(obj interface{}, values map[string][]string) error {
+	return mapping(obj, "form", values, nil)
+}
+
+// SetDefaults 设置 default tag 中的默认值
+//	只设置零值的字段，slice 使用逗号分隔
+//		Page int      `query:"page" default:"1"`
+//		Sort []string `query:"sort" default:"id,name"`
+func SetDefaults(obj interface{}) error {
+	v, err := structValue(obj)
+	if err != nil {
+		return err
+	}
+	_, err = walk(v, "", func(field reflect.StructField, value reflect.Value, path string) (bool, error) {
+		def, ok := field.Tag.Lookup("default")
+		if !ok || !value.IsZero() {
+			return false, nil
+		}
+		vals := []string{def}
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			vals = strings.Split(def, ",")
+		}
+		if err := setField(value, field, vals); err != nil {
+			return false, &FieldError{Field: path, Name: field.Name, Source: "default", Value: def, Err: err}
+		}
+		return true, nil
+	})
+	return err
+}
+
+// mapping 按 tag 把 values 和 files 绑定到 obj
+func mapping(obj interface{}, tag string, values map[string][]string, files map[string][]*multipart.FileHeader) error {
+	v, err := structValue(obj)
+	if err != nil {
+		return err
+	}
+	_, err = walk(v, "", func(field reflect.StructField, value reflect.Value, path string) (bool, error) {
+		name := tagName(field, tag)
+		if name == "" {
+			return false, nil
+		}
+		if files != nil && isFileField(field.Type) {
+			return setFiles(value, files[name]), nil
+		}
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			return false, nil
+		}
+		if err := setField(value, field, vals); err != nil {
+			return false, &FieldError{Field: path, Name: name, Source: tag, Value: strings.Join(vals, ","), Err: err}
+		}
+		return true, nil
+	})
+	return err
+}
+
+// structValue obj 必须是结构体指针
+func structValue(obj interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, ErrInvalidObj
+	}
+	return v.Elem(), nil
+}
+
+// walk 遍历结构体字段，包括匿名和嵌套的结构体
+//	fn 返回是否设置了字段；结构体指针只在设置了字段时才分配
+func walk(v reflect.Value, prefix string, fn func(reflect.StructField, reflect.Value, string) (bool, error)) (bool, error) {
+	var set bool
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		value := v.Field(i)
+		path := prefix + field.Name
+
+		if isNestedStruct(field) {
+			ft := field.Type
+			isPtr := ft.Kind() == reflect.Ptr
+			if isPtr {
+				ft = ft.Elem()
+			}
+			nested := reflect.New(ft).Elem()
+			if isPtr && !value.IsNil() {
+				nested = value.Elem()
+			} else if !isPtr {
+				nested = value
+			}
+			childPrefix := path + "."
+			if field.Anonymous {
+				childPrefix = prefix
+			}
+			ok, err := walk(nested, childPrefix, fn)
+			if err != nil {
+				return set, err
+			}
+			if ok && isPtr && value.IsNil() {
+				if !value.CanSet() {
+					continue
+				}
+				ptr := reflect.New(ft)
+				ptr.Elem().Set(nested)
+				value.Set(ptr)
+			}
+			set = set || ok
+			continue
+		}
+
+		if !value.CanSet() {
+			continue
+		}
+		ok, err := fn(field, value, path)
+		if err != nil {
+			return set, err
+		}
+		set = set || ok
+	}
+	return set, nil
+}
+
+// isNestedStruct 没有绑定 tag 的结构体字段，需要继续遍历
+func isNestedStruct(field reflect.StructField) bool {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return false
+	}
+	for _, tag := range []string{"form", "query", "header", "uri", "default"} {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// tagName 返回 tag 中的名称，"-" 表示忽略
+func tagName(field reflect.StructField, tag string) string {
+	name := field.Tag.Get(tag)
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// isFileField *multipart.FileHeader 或 []*multipart.FileHeader
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+// setFiles 设置上传的文件
+func setFiles(value reflect.Value, files []*multipart.FileHeader) bool {
+	if len(files) == 0 {
+		return false
+	}
+	if value.Kind() == reflect.Slice {
+		value.Set(reflect.ValueOf(files))
+	} else {
+		value.Set(reflect.ValueOf(files[0]))
+	}
+	return true
+}
+
+// setField 设置字段，slice 和 array 使用全部的值，其他类型使用第一个值
+func setField(value reflect.Value, field reflect.StructField, vals []string) error {
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			value.SetBytes([]byte(vals[0]))
+			return nil
+		}
+		slice := reflect.MakeSlice(value.Type(), len(vals), len(vals))
+		for i, s := range vals {
+			if err := setValue(slice.Index(i), field, s); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	case reflect.Array:
+		if len(vals) != value.Len() {
+			return fmt.Errorf("%d values for an array of length %d", len(vals), value.Len())
+		}
+		for i, s := range vals {
+			if err := setValue(value.Index(i), field, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return setValue(value, field, vals[0])
+}
+
+// setValue 把字符串转换为字段的类型
+func setValue(value reflect.Value, field reflect.StructField, s string) error {
+	if value.Kind() == reflect.Ptr {
+		if s == "" {
+			return nil
+		}
+		ptr := reflect.New(value.Type().Elem())
+		if err := setValue(ptr.Elem(), field, s); err != nil {
+			return err
+		}
+		value.Set(ptr)
+		return nil
+	}
+
+	switch value.Type() {
+	case timeType:
+		return setTime(value, field, s)
+	case durationType:
+		if s == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+	if u, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			return nil
+		}
+		if s == "on" {
+			s = "true"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		value.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// setTime 使用 time_format tag 解析时间，默认为 RFC3339
+//	time_format 为 unix unixmilli unixnano 时，按时间戳解析
+//	time_utc:"1" 时使用 UTC，否则使用本地时区
+func setTime(value reflect.Value, field reflect.StructField, s string) error {
+	if s == "" {
+		return nil
+	}
+	format := field.Tag.Get("time_format")
+	switch format {
+	case "unix", "unixmilli", "unixnano":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return numError(err)
+		}
+		var t time.Time
+		switch format {
+		case "unix":
+			t = time.Unix(n, 0)
+		case "unixmilli":
+			t = time.Unix(0, n*int64(time.Millisecond))
+		default:
+			t = time.Unix(0, n)
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	case "":
+		format = time.RFC3339
+	}
+
+	loc := time.Local
+	if field.Tag.Get("time_utc") == "1" {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(format, s, loc)
+	if err != nil {
+		return err
+	}
+	value.Set(reflect.ValueOf(t))
+	return nil
+}
+
+// numError 去掉 strconv.NumError 中重复的值
+func numError(err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		return ne.Err
+	}
+	return err
+}
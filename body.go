@@ -0,0 +1,174 @@
+package gow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gkzy/gow/binding"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrBodyTooLarge 请求体超过 Engine.MaxBodySize 或 BodyLimit 的限制
+var ErrBodyTooLarge = RegisterError(http.StatusRequestEntityTooLarge, http.StatusRequestEntityTooLarge, map[string]string{"zh": "请求体太大", "en": "request entity too large"})
+
+// BodyLimit 设置分组或路由的请求体大小限制，覆盖 Engine.MaxBodySize
+//	Content-Length 超过限制时，在执行路由的 handler 之前返回 413
+//	没有 Content-Length 或使用 gzip 时，读取超过限制返回 ErrBodyTooLarge
+//	n 为0时不限制
+//		upload := r.Group("/upload", gow.BodyLimit(100<<20))
+func BodyLimit(n int64) HandlerFunc {
+	return func(c *Context) {
+		if c.reqBody != nil {
+			c.reqBody.limit = n
+		}
+		c.Next()
+	}
+}
+
+// bodyTooLarge Content-Length 是否超过限制
+//	在执行路由的 handler 之前调用，此时 BodyLimit 已经生效
+func (c *Context) bodyTooLarge() bool {
+	b := c.reqBody
+	return b != nil && b.limit > 0 && b.length > b.limit
+}
+
+// abortBodyError 读取请求体失败(超过限制或 gzip 解码失败)时返回错误响应
+//	返回 true 时已停止执行后续的 handler
+func (c *Context) abortBodyError() bool {
+	if c.reqBody == nil || c.reqBody.err == nil {
+		return false
+	}
+	if !c.IsAborted() {
+		c.AbortWithError(c.reqBody.err)
+	}
+	return true
+}
+
+// parseForm 解析 query 和表单，multipart 时同时解析上传的文件
+//	请求体超过限制时返回 413，见 abortBodyError
+func (c *Context) parseForm() {
+	if c.Req.Form != nil {
+		return
+	}
+	c.cacheFormBody()
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType == binding.MIMEMultipartPOSTForm {
+		c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory)
+	} else {
+		c.Req.ParseForm()
+	}
+	c.abortBodyError()
+}
+
+// BodyBytes 读取并缓存请求体，可以多次调用
+//	读取后 c.Req.Body 会重新指向缓存，DecodeJSONBody Bind GetString 等方法仍然可以读取
+//		// 验签中间件
+//		body, err := c.BodyBytes()
+//		if err != nil || !verify(body, c.GetHeader("X-Sign")) {
+//			c.AbortWithError(gow.ErrForbidden)
+//			return
+//		}
+func (c *Context) BodyBytes() ([]byte, error) {
+	if !c.bodyCached {
+		c.bodyCached = true
+		if c.Req.Body != nil {
+			c.bodyBytes, c.bodyErr = ioutil.ReadAll(c.Req.Body)
+		}
+	}
+	c.resetBody()
+	return c.bodyBytes, c.bodyErr
+}
+
+// resetBody 请求体已缓存时，c.Req.Body 重新从头读取
+func (c *Context) resetBody() {
+	if c.bodyCached && c.Req.Body != nil {
+		c.Req.Body = ioutil.NopCloser(bytes.NewReader(c.bodyBytes))
+	}
+}
+
+// cacheFormBody application/x-www-form-urlencoded 在 ParseForm 之前缓存请求体
+func (c *Context) cacheFormBody() {
+	if c.Req.Form != nil || c.bodyCached {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType == binding.MIMEPOSTForm {
+		c.BodyBytes()
+	}
+}
+
+// wrapBody 使用 Engine.MaxBodySize 限制请求体，并解码 gzip
+func (c *Context) wrapBody() {
+	req := c.Req
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	b := &requestBody{
+		src:    req.Body,
+		limit:  c.engine.MaxBodySize,
+		length: req.ContentLength,
+	}
+	if strings.EqualFold(strings.TrimSpace(req.Header.Get("Content-Encoding")), "gzip") {
+		b.gzip = true
+		req.Header.Del("Content-Encoding")
+		req.ContentLength = -1
+	}
+	c.reqBody = b
+	req.Body = b
+}
+
+// requestBody 限制大小并解码 gzip 的请求体
+//	限制作用于解码后的大小，防止 gzip 炸弹
+type requestBody struct {
+	src    io.ReadCloser
+	r      io.Reader
+	gzip   bool
+	limit  int64 //为0时不限制
+	length int64 //请求的 Content-Length
+	read   int64
+	err    error
+}
+
+func (b *requestBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.r == nil {
+		if b.limit > 0 && b.length > b.limit {
+			b.err = ErrBodyTooLarge
+			return 0, b.err
+		}
+		b.r = b.src
+		if b.gzip {
+			zr, err := gzip.NewReader(b.src)
+			if err != nil {
+				b.err = ErrBadRequest.Wrap(err)
+				return 0, b.err
+			}
+			b.r = zr
+		}
+	}
+	// 最多多读一个字节，用来判断是否超过限制
+	if b.limit > 0 {
+		if max := b.limit - b.read + 1; int64(len(p)) > max {
+			p = p[:max]
+		}
+	}
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.limit > 0 && b.read > b.limit {
+		b.err = ErrBodyTooLarge
+		return 0, b.err
+	}
+	if err != nil && err != io.EOF && b.gzip {
+		err = ErrBadRequest.Wrap(err)
+	}
+	return n, err
+}
+
+func (b *requestBody) Close() error {
+	return b.src.Close()
+}
@@ -0,0 +1,106 @@
+package gow
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// streamBody 没有 Content-Length 的请求体，如 chunked
+type streamBody struct {
+	io.Reader
+}
+
+func gzipString(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestBodyLimit(t *testing.T) {
+	r := New()
+	r.MaxBodySize = 10
+	form := func(c *Context) {
+		v := c.GetString("name")
+		if c.IsAborted() {
+			return
+		}
+		c.String(v)
+	}
+	r.POST("/form", form)
+	r.POST("/json", func(c *Context) {
+		var v map[string]string
+		if err := c.DecodeJSONBody(&v); err != nil {
+			return
+		}
+		c.String(v["a"])
+	})
+	r.POST("/bind", func(c *Context) {
+		var v struct {
+			Name string `form:"name"`
+		}
+		if err := c.Bind(&v); err != nil {
+			return
+		}
+		c.String(v.Name)
+	})
+	big := r.Group("/big", BodyLimit(1000))
+	big.POST("/form", form)
+
+	formType := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	jsonType := map[string]string{"Content-Type": "application/json"}
+	gzipForm := map[string]string{"Content-Type": "application/x-www-form-urlencoded", "Content-Encoding": "gzip"}
+	long := "name=" + strings.Repeat("a", 20)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    io.Reader
+		headers map[string]string
+		code    int
+		want    string
+	}{
+		{"form within limit", "/form", strings.NewReader("name=ab"), formType, http.StatusOK, "ab"},
+		{"form content-length", "/form", strings.NewReader(long), formType, http.StatusRequestEntityTooLarge, ""},
+		{"form chunked", "/form", &streamBody{strings.NewReader(long)}, formType, http.StatusRequestEntityTooLarge, ""},
+		{"json content-length", "/json", strings.NewReader(`{"a":"bcdefghijk"}`), jsonType, http.StatusRequestEntityTooLarge, ""},
+		{"json chunked", "/json", &streamBody{strings.NewReader(`{"a":"bcdefghijk"}`)}, jsonType, http.StatusRequestEntityTooLarge, ""},
+		{"bind chunked", "/bind", &streamBody{strings.NewReader(long)}, formType, http.StatusRequestEntityTooLarge, ""},
+		{"group raises limit", "/big/form", strings.NewReader(long), formType, http.StatusOK, strings.Repeat("a", 20)},
+		// Content-Length 是压缩后的长度
+		{"gzip content-length", "/form", bytes.NewReader(gzipString("name=ab")), gzipForm, http.StatusRequestEntityTooLarge, ""},
+		{"gzip chunked within limit", "/form", &streamBody{bytes.NewReader(gzipString("name=ab"))}, gzipForm, http.StatusOK, "ab"},
+		// 解压后超过限制
+		{"gzip bomb", "/form", &streamBody{bytes.NewReader(gzipString("name=" + strings.Repeat("a", 1000)))}, gzipForm, http.StatusRequestEntityTooLarge, ""},
+		{"gzip in group", "/big/form", bytes.NewReader(gzipString(long)), gzipForm, http.StatusOK, strings.Repeat("a", 20)},
+		{"invalid gzip", "/form", strings.NewReader("name=ab"), gzipForm, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, "POST", tt.path, tt.body, tt.headers)
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d, body %s", tt.name, w.Code, tt.code, w.Body.String())
+			continue
+		}
+		if tt.code == http.StatusOK && w.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.name, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestBodyBytes(t *testing.T) {
+	r := New()
+	r.POST("/", func(c *Context) {
+		b1, _ := c.BodyBytes()
+		b2, _ := c.BodyBytes()
+		c.String(string(b1) + "|" + string(b2) + "|" + c.GetString("name"))
+	})
+	w := performRequest(r, "POST", "/", strings.NewReader("name=gow"), map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+	if got, want := w.Body.String(), "name=gow|name=gow|gow"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package gow
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SetTrustedProxies 设置可信的代理，支持 CIDR 和单个 IP(IPv4/IPv6)
+//	请求来自可信代理时，GetIP 从 Forwarded X-Forwarded-For X-Real-IP 中读取客户端 IP
+//	为空时不信任任何代理，GetIP 返回 RemoteAddr 中的 IP
+//		r.SetTrustedProxies("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1")
+func (engine *Engine) SetTrustedProxies(proxies ...string) error {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		ipNet, err := parseCIDR(strings.TrimSpace(p))
+		if err != nil {
+			return err
+		}
+		nets = append(nets, ipNet)
+	}
+	engine.trustedProxies = nets
+	return nil
+}
+
+// parseCIDR 解析 CIDR，单个 IP 时使用 /32 或 /128
+func parseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("gow: invalid trusted proxy %q", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("gow: invalid trusted proxy %q", s)
+	}
+	return ipNet, nil
+}
+
+// isTrustedProxy ip 是否为可信的代理
+func (engine *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, ipNet := range engine.trustedProxies {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP 解析客户端 IP
+//	RemoteAddr 不是可信代理时直接返回；否则从右向左遍历转发链，返回第一个不可信的地址
+func (engine *Engine) clientIP(c *Context) string {
+	remote := parseHop(c.Req.RemoteAddr)
+	if remote == nil {
+		return ""
+	}
+	if !engine.isTrustedProxy(remote) {
+		return remote.String()
+	}
+
+	hops := forwardedFor(c.Req.Header.Get("Forwarded"))
+	if len(hops) == 0 {
+		hops = splitHeader(c.Req.Header.Values("X-Forwarded-For"))
+	}
+	if len(hops) == 0 {
+		if ip := parseHop(c.Req.Header.Get("X-Real-IP")); ip != nil {
+			return ip.String()
+		}
+		return remote.String()
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHop(hops[i])
+		if ip == nil {
+			// unknown 或混淆的地址，无法继续向前追溯
+			break
+		}
+		client = ip
+		if !engine.isTrustedProxy(ip) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedFor 读取 Forwarded (RFC 7239) 中的 for 参数
+//	Forwarded: for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"
+func forwardedFor(header string) []string {
+	if header == "" {
+		return nil
+	}
+	var hops []string
+	for _, elem := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(elem, ";") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+				hops = append(hops, strings.Trim(kv[1], `"`))
+			}
+		}
+	}
+	return hops
+}
+
+// splitHeader 拆分多个以逗号分隔的 header
+func splitHeader(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseHop 解析地址，支持 1.2.3.4 1.2.3.4:80 ::1 [::1] [::1]:80
+func parseHop(s string) net.IP {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	} else {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	}
+	// 去掉 IPv6 的 zone，如 fe80::1%eth0
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		s = s[:i]
+	}
+	return net.ParseIP(s)
+}
@@ -0,0 +1,56 @@
+package gow
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	r := New()
+	if err := r.SetTrustedProxies("10.0.0.0/8", "::1"); err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/", func(c *Context) {
+		c.String(c.GetIP())
+	})
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"untrusted remote ignores headers", "1.2.3.4:5678", map[string]string{"X-Forwarded-For": "9.9.9.9", "X-Real-IP": "8.8.8.8"}, "1.2.3.4"},
+		{"trusted remote without headers", "10.0.0.1:80", nil, "10.0.0.1"},
+		{"x-forwarded-for", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "9.9.9.9"}, "9.9.9.9"},
+		{"spoofed leftmost hop", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6, 9.9.9.9, 10.0.0.2"}, "9.9.9.9"},
+		{"all hops trusted", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"unknown hop stops", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "9.9.9.9, unknown, 10.0.0.2"}, "10.0.0.2"},
+		{"x-real-ip", "10.0.0.1:80", map[string]string{"X-Real-IP": "8.8.8.8"}, "8.8.8.8"},
+		{"forwarded ipv6", "10.0.0.1:80", map[string]string{"Forwarded": `for=192.0.2.60;proto=http, for="[2001:db8::17]:4711"`}, "2001:db8::17"},
+		{"forwarded before x-forwarded-for", "10.0.0.1:80", map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "9.9.9.9"}, "192.0.2.60"},
+		{"trusted ipv6 remote", "[::1]:80", map[string]string{"X-Forwarded-For": "9.9.9.9"}, "9.9.9.9"},
+		{"untrusted ipv6 remote", "[2001:db8::1]:80", map[string]string{"X-Forwarded-For": "9.9.9.9"}, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remote
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: GetIP() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetTrustedProxies(t *testing.T) {
+	r := New()
+	for _, p := range []string{"10.0.0.0/33", "example.com", ""} {
+		if err := r.SetTrustedProxies(p); err == nil {
+			t.Errorf("SetTrustedProxies(%q) should return an error", p)
+		}
+	}
+}
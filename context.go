@@ -1,12 +1,17 @@
 package gow
 
 import (
+	"bytes"
+	"context"
+	"crypto/x509"
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"fmt"
+	"github.com/gkzy/gow/binding"
 	"github.com/gkzy/gow/render"
+	"github.com/gkzy/gow/session"
+	"github.com/gkzy/gow/validate"
 	"io"
-	"io/ioutil"
 	"math"
 	"mime/multipart"
 	"net/http"
@@ -15,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 //Context gow context
@@ -31,11 +37,26 @@ type Context struct {
 	StatusCode     int
 	// Data html template data map
 	Data           map[interface{}]interface{}
+	// Errors c.Error 记录的错误，见 ErrorHandler
+	Errors []error
 
 	mu       sync.RWMutex
 	index    int8
-	engine   *Engine
-	fullPath string
+	engine    *Engine
+	fullPath  string
+	session   *session.Session
+
+	flashes     map[string]string
+	flashLoaded bool
+	requestID   string
+
+	reqBody    *requestBody
+	bodyBytes  []byte
+	bodyErr    error
+	bodyCached bool
+
+	sameSite http.SameSite
+	lang     string
 }
 
 const (
@@ -48,13 +69,30 @@ func (c *Context) reset() {
 	c.handlers = nil
 	c.index = -1
 	c.fullPath = ""
+	c.IP = ""
 	c.Keys = nil
 	c.Data = nil
+	c.session = nil
+	c.flashes = nil
+	c.flashLoaded = false
+	c.Errors = c.Errors[0:0]
+	c.requestID = ""
+	c.reqBody = nil
+	c.bodyBytes = nil
+	c.bodyErr = nil
+	c.bodyCached = false
+	c.sameSite = 0
+	c.lang = ""
 }
 
 func (c *Context) Next() {
 	c.index++
 	for c.index < int8(len(c.handlers)) {
+		// 执行路由的 handler 之前检查请求体大小，此时分组的 BodyLimit 已经生效
+		if int(c.index) == len(c.handlers)-1 && c.bodyTooLarge() {
+			c.AbortWithError(ErrBodyTooLarge)
+			return
+		}
 		c.handlers[c.index](c)
 		c.index++
 	}
@@ -69,7 +107,7 @@ func (c *Context) HandlerName() string {
 func (c *Context) AbortCode(statusCode int) {
 	c.Status(statusCode)
 	c.Writer.WriteHeaderNow()
-	c.Abort()
+	c.StopRun()
 }
 
 // StopRun stop run
@@ -77,27 +115,35 @@ func (c *Context) StopRun() {
 	c.index = abortIndex
 }
 
+// IsAborted 是否已停止执行后续的 handler
+//	c.GetString 等方法读取请求体失败时会返回413并停止，handler 可以据此提前返回
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
 //Abort abort http response
 func (c *Context) Abort() {
 	c.AbortCode(http.StatusNonAuthoritativeInfo)
 }
 
 //Fail fail http response
+//	使用 AbortWithError 返回统一的错误响应(json 或 html 错误页面)，err 作为消息返回给客户端
+//		c.Fail(400, "mobile is invalid") // {"code":400,"msg":"mobile is invalid","data":null,"request_id":"..."}
 func (c *Context) Fail(statusCode int, err string) {
-	//TODO:日志记录
-	c.AbortCode(statusCode)
-	_, _ = c.Writer.Write([]byte(err))
+	c.AbortWithError(&BizError{
+		Code:     statusCode,
+		Status:   statusCode,
+		Messages: map[string]string{"en": err},
+	})
 }
 
 // GetIP get client ip address
-func (c *Context) GetIP() (ip string) {
-	addr := c.Req.RemoteAddr
-	str := strings.Split(addr, ":")
-	if len(str) > 1 {
-		ip = str[0]
+//	请求来自可信代理时，从 Forwarded X-Forwarded-For X-Real-IP 中读取，见 SetTrustedProxies
+func (c *Context) GetIP() string {
+	if c.IP == "" {
+		c.IP = c.engine.clientIP(c)
 	}
-	c.IP = ip
-	return
+	return c.IP
 }
 
 //SetKey
@@ -149,6 +195,19 @@ func (c *Context) Status(statusCode int) {
 	c.Writer.WriteHeader(statusCode)
 }
 
+// Render 使用 r 写入响应
+//	自定义的格式实现 render.Render 即可
+//		c.Render(http.StatusOK, render.YAML{Data: data})
+func (c *Context) Render(statusCode int, r render.Render) {
+	if statusCode < 0 {
+		statusCode = http.StatusOK
+	}
+	c.Status(statusCode)
+	if err := r.Render(c.Writer); err != nil {
+		c.AbortWithError(ErrInternal.Wrap(err))
+	}
+}
+
 // WriteString response text
 func (c *Context) ServerString(statusCode int, msg string) {
 	c.Writer.Header().Set("Content-Type", "text/plain;charset=utf-8")
@@ -163,19 +222,7 @@ func (c *Context) String(msg string) {
 
 // ServerJSON response json format
 func (c *Context) ServerJSON(statusCode int, data interface{}) {
-	if statusCode < 0 {
-		statusCode = http.StatusOK
-	}
-	c.SetHeader("Content-Type", "application/json; charset=utf-8")
-	c.Status(statusCode)
-
-	encoder := json.NewEncoder(c.Writer)
-	if c.engine.RunMode == devMode {
-		encoder.SetIndent("", "  ")
-	}
-	if err := encoder.Encode(data); err != nil {
-		c.Fail(http.StatusServiceUnavailable, err.Error())
-	}
+	c.Render(statusCode, render.JSON{Data: data, Indent: c.engine.RunMode == devMode})
 }
 
 // JSON response successful json format
@@ -185,15 +232,7 @@ func (c *Context) JSON(v interface{}) {
 
 // ServerXML response xml
 func (c *Context) ServerXML(statusCode int, data interface{}) {
-	if statusCode < 0 {
-		statusCode = http.StatusOK
-	}
-	c.SetHeader("Content-Type", "application/xml; charset=utf-8")
-	c.Status(statusCode)
-	encoder := xml.NewEncoder(c.Writer)
-	if err := encoder.Encode(data); err != nil {
-		c.Fail(http.StatusServiceUnavailable, err.Error())
-	}
+	c.Render(statusCode, render.XML{Data: data})
 }
 
 //XML XML
@@ -201,6 +240,70 @@ func (c *Context) XML(data interface{}) {
 	c.ServerXML(http.StatusOK, data)
 }
 
+// ServerPureJSON 不转义 html 字符的 json
+func (c *Context) ServerPureJSON(statusCode int, data interface{}) {
+	c.Render(statusCode, render.PureJSON{Data: data})
+}
+
+// PureJSON PureJSON
+//		c.PureJSON(gow.H{"html": "<b>gow</b>"})
+func (c *Context) PureJSON(data interface{}) {
+	c.ServerPureJSON(http.StatusOK, data)
+}
+
+// ServerSecureJSON 返回数组时，加上 engine.SecureJSONPrefix 前缀，防止 json 劫持
+func (c *Context) ServerSecureJSON(statusCode int, data interface{}) {
+	c.Render(statusCode, render.SecureJSON{Prefix: c.engine.SecureJSONPrefix, Data: data})
+}
+
+// SecureJSON SecureJSON
+func (c *Context) SecureJSON(data interface{}) {
+	c.ServerSecureJSON(http.StatusOK, data)
+}
+
+// ServerJSONP jsonp，callback 为 query 中 engine.JSONPCallback 的值
+//	没有 callback 时返回 json
+func (c *Context) ServerJSONP(statusCode int, data interface{}) {
+	c.Render(statusCode, render.JSONP{Callback: c.Query(c.engine.JSONPCallback), Data: data})
+}
+
+// JSONP JSONP
+//		// GET /user?callback=show
+//		c.JSONP(user) // /**/ typeof show === 'function' && show({...});
+func (c *Context) JSONP(data interface{}) {
+	c.ServerJSONP(http.StatusOK, data)
+}
+
+// ServerYAML yaml
+func (c *Context) ServerYAML(statusCode int, data interface{}) {
+	c.Render(statusCode, render.YAML{Data: data})
+}
+
+// YAML YAML
+func (c *Context) YAML(data interface{}) {
+	c.ServerYAML(http.StatusOK, data)
+}
+
+// ServerProtoBuf protocol buffers，data 必须是 proto.Message
+func (c *Context) ServerProtoBuf(statusCode int, data interface{}) {
+	c.Render(statusCode, render.ProtoBuf{Data: data})
+}
+
+// ProtoBuf ProtoBuf
+func (c *Context) ProtoBuf(data interface{}) {
+	c.ServerProtoBuf(http.StatusOK, data)
+}
+
+// ServerMsgPack MessagePack
+func (c *Context) ServerMsgPack(statusCode int, data interface{}) {
+	c.Render(statusCode, render.MsgPack{Data: data})
+}
+
+// MsgPack MsgPack
+func (c *Context) MsgPack(data interface{}) {
+	c.ServerMsgPack(http.StatusOK, data)
+}
+
 // ServerHTML ServerHTML
 func (c *Context) ServerHTML(statusCode int, name string) {
 	//未设置 AutoRender时，不渲染模板
@@ -208,11 +311,17 @@ func (c *Context) ServerHTML(statusCode int, name string) {
 		c.ServerString(404, string(default404Body))
 		return
 	}
+	if _, ok := c.Data[flashDataKey]; !ok {
+		c.Data[flashDataKey] = c.Flashes()
+	}
+	if _, ok := c.Data[langDataKey]; !ok {
+		c.Data[langDataKey] = c.Lang()
+	}
 	c.Status(statusCode)
 	c.engine.HTMLRender = render.HTMLRender{}.Instance(c.engine.viewsPath, name, c.engine.FuncMap, c.engine.delims, c.engine.AutoRender, c.engine.RunMode, c.Data)
 	err := c.engine.HTMLRender.Render(c.Writer)
 	if err != nil {
-		c.Fail(http.StatusServiceUnavailable, err.Error())
+		c.AbortWithError(ErrInternal.Wrap(err))
 	}
 }
 
@@ -222,21 +331,114 @@ func (c *Context) HTML(name string) {
 }
 
 // DecodeJSONBody json decoder request.Body to v
+//	使用缓存的请求体，可以多次调用，见 BodyBytes
+//	请求体超过限制时返回413并停止执行后续的 handler
 func (c *Context) DecodeJSONBody(v interface{}) error {
-	decoder := json.NewDecoder(c.Req.Body)
-	if err := decoder.Decode(&v); err != nil {
+	body, err := c.BodyBytes()
+	if err != nil {
+		c.abortBodyError()
+		return err
+	}
+	return json.NewDecoder(bytes.NewReader(body)).Decode(v)
+}
+
+// Bind 绑定请求到 obj，失败时返回400，字段的错误消息放在 Response 的 data 中
+//	obj 必须是结构体指针，详见 ShouldBind
+//		var req UserRequest
+//		if err := c.Bind(&req); err != nil {
+//			return
+//		}
+func (c *Context) Bind(obj interface{}) error {
+	if err := c.ShouldBind(obj); err != nil {
+		c.AbortWithError(c.bindError(err))
 		return err
 	}
 	return nil
 }
 
+// bindError 把绑定和校验错误转为 ErrBadRequest
+//	data 为 Field -> 错误消息，使用 c.Lang() 的语言
+//	validate tag 不正确是程序错误，转为 ErrInternal
+//		{"code":400,"msg":"请求参数错误","data":{"Name":"用户名不能为空"},"request_id":"..."}
+func (c *Context) bindError(err error) error {
+	var be *BizError
+	if errors.As(err, &be) {
+		return err
+	}
+	var te *validate.TagError
+	if errors.As(err, &te) {
+		return ErrInternal.Wrap(err)
+	}
+	var errs validate.Errors
+	if errors.As(err, &errs) {
+		return ErrBadRequest.Wrap(err).WithData(errs.Translate(c.Lang()))
+	}
+	var fe *binding.FieldError
+	if errors.As(err, &fe) {
+		return ErrBadRequest.Wrap(err).WithData(map[string]string{
+			fe.Name: validate.Default.Message(c.Lang(), "default", fe.Name),
+		})
+	}
+	return ErrBadRequest.Wrap(err)
+}
+
+// ShouldBind 绑定请求到 obj
+//	根据 Method 和 Content-Type 选择 body 的 binding (form json xml multipart)
+//	依次设置 default，绑定 uri header query，最后绑定 body
+//	字段绑定失败时返回 *binding.FieldError，绑定后使用 validate tag 校验，失败时返回 validate.Errors
+func (c *Context) ShouldBind(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.Default(c.Req.Method, c.GetHeader("Content-Type")))
+}
+
+// ShouldBindWith 使用指定的 binding 绑定 body
+//		c.ShouldBindWith(&req, binding.JSON)
+func (c *Context) ShouldBindWith(obj interface{}, b binding.Binding) error {
+	if err := binding.SetDefaults(obj); err != nil {
+		return err
+	}
+	if len(c.Params) > 0 {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.URI.BindURI(params, obj); err != nil {
+			return err
+		}
+	}
+	if err := binding.Header.Bind(c.Req, obj); err != nil {
+		return err
+	}
+	if err := binding.Query.Bind(c.Req, obj); err != nil {
+		return err
+	}
+	switch b {
+	case binding.JSON, binding.XML:
+		if _, err := c.BodyBytes(); err != nil {
+			return err
+		}
+	case binding.FormMultipart, binding.Form, binding.FormPost:
+		c.cacheFormBody()
+		if b != binding.FormPost {
+			c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory)
+		}
+		// ParseForm 不返回读取请求体的错误，如超过大小限制
+		if c.reqBody != nil && c.reqBody.err != nil {
+			return c.reqBody.err
+		}
+	}
+	if err := b.Bind(c.Req, obj); err != nil {
+		return err
+	}
+	return binding.Validate(obj)
+}
+
 // RequestBody request body
+//	可以多次调用，需要读取错误时使用 BodyBytes
 func (c *Context) RequestBody() []byte {
-	if c.Req.Body == nil {
+	b, _ := c.BodyBytes()
+	if b == nil {
 		return []byte{}
 	}
-	var b []byte
-	b, _ = ioutil.ReadAll(c.Req.Body)
 	return b
 }
 
@@ -256,27 +458,21 @@ func (c *Context) Query(key string) string {
 }
 
 // Form
+//	请求体超过限制时返回413并停止执行后续的 handler，见 IsAborted
 func (c *Context) Form(key string) string {
+	c.parseForm()
 	return c.Req.FormValue(key)
 }
 
 //input
 func (c *Context) input() url.Values {
-	if c.Req.Form == nil {
-		c.Req.ParseForm()
-	}
+	c.parseForm()
 	return c.Req.Form
 }
 
 //formValue formValue
 func (c *Context) formValue(key string) string {
-	if v := c.Form(key); v != "" {
-		return v
-	}
-	if c.Req.Form == nil {
-		c.Req.ParseForm()
-	}
-	return c.Req.Form.Get(key)
+	return c.input().Get(key)
 }
 
 //GetString 按key返回字串值，可以设置default值
@@ -427,10 +623,10 @@ func (c *Context) Redirect(statusCode int, url string) {
 }
 
 //Download	data to download
+//	支持 Range；需要文件名时使用 DownloadReader 或 Attachment
 func (c *Context) Download(data []byte) {
 	c.SetHeader("Content-Type", "application/octet-stream; charset=utf-8")
-	c.Status(200)
-	c.Writer.Write(data)
+	c.DownloadReader(bytes.NewReader(data), "", time.Time{})
 }
 
 // GetCookie get request cookie
@@ -446,6 +642,7 @@ func (c *Context) GetCookie(key string) string {
 }
 
 // SetCookie set cookie
+//	SameSite 使用 c.SetSameSite 或 Engine.CookieSameSite 的值
 // 		c.SetCookie("url","https://gow.22v.net",72*time.Hour,"",true,true)
 func (c *Context) SetCookie(key, value string, maxAge int, path, domain string, secure, httpOnly bool) {
 	if path == "" {
@@ -457,7 +654,7 @@ func (c *Context) SetCookie(key, value string, maxAge int, path, domain string,
 		MaxAge:   maxAge,
 		Path:     path,
 		Domain:   domain,
-		SameSite: http.SameSiteDefaultMode,
+		SameSite: c.cookieSameSite(),
 		Secure:   secure,
 		HttpOnly: httpOnly,
 	})
@@ -529,16 +726,25 @@ func (c *Context) IsWebsocket() bool {
 	return false
 }
 
-//================== memory session ======================
+// ClientCertificate 返回校验通过的客户端证书
+//	需要 engine.SetClientCA 开启客户端证书校验，没有时返回nil
+func (c *Context) ClientCertificate() *x509.Certificate {
+	if c.Req.TLS == nil || len(c.Req.TLS.VerifiedChains) == 0 || len(c.Req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return c.Req.TLS.VerifiedChains[0][0]
+}
+
+//================== session ======================
 
 // SetSession
 func (c *Context) SetSession(key string, v interface{}) {
-	setSession(key, v)
+	setSession(c, key, v)
 }
 
 // GetSession  return interface{}
 func (c *Context) GetSession(key string) interface{} {
-	return getSession(key)
+	return getSession(c, key)
 }
 
 //GetSessionString GetSessionString
@@ -583,5 +789,74 @@ func (c *Context) GetSessionBool(key string) bool {
 
 // DeleteSession delete session key
 func (c *Context) DeleteSession(key string) {
-	deleteSession(key)
+	deleteSession(c, key)
+}
+
+// RegenerateSession 更换 sessionID，保留 session 中的值
+//	登录成功后调用，防止 session fixation
+//		c.SetSession("uid", uid)
+//		c.RegenerateSession()
+func (c *Context) RegenerateSession() error {
+	if c.session == nil {
+		return errors.New("session is not started, please use gow.Session()")
+	}
+	return c.session.Regenerate()
+}
+
+// BindSessionUser 把当前 session 关联到 userID
+//	关联后，可以通过 gow.SessionManager() 查询和注销该用户的 session
+//		c.RegenerateSession()
+//		c.BindSessionUser(uid)
+func (c *Context) BindSessionUser(userID string) error {
+	if c.session == nil {
+		return errors.New("session is not started, please use gow.Session()")
+	}
+	return sessionManager.BindUser(c.session, userID, c.GetIP(), c.UserAgent())
+}
+
+//================== context.Context ======================
+
+var _ context.Context = &Context{}
+
+// Deadline 返回请求 context 的 Deadline，实现 context.Context
+//	*gow.Context 可以直接传给需要 context.Context 的方法，如 gorm grpc
+//		client.VerifyWebToken(c, token)
+//	Context 在请求结束后会被复用，不能在请求结束后的 goroutine 中使用
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.Req == nil {
+		return
+	}
+	return c.Req.Context().Deadline()
+}
+
+// Done 请求结束、客户端断开或超时(见 Timeout)时 close
+func (c *Context) Done() <-chan struct{} {
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Done()
+}
+
+// Err 返回请求 context 的 Err
+func (c *Context) Err() error {
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Err()
+}
+
+// Value key 为 string 时先读取 SetKey 保存的值，再读取请求 context 中的值
+func (c *Context) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		c.mu.RLock()
+		v, exists := c.Keys[k]
+		c.mu.RUnlock()
+		if exists {
+			return v
+		}
+	}
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Value(key)
 }
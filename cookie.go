@@ -0,0 +1,202 @@
+package gow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/gkzy/gow/lib/util"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	randomSecretOnce sync.Once
+	randomSecret     string
+)
+
+// secure cookie 中 payload 的类型
+const (
+	cookiePlain     = "p"
+	cookieEncrypted = "e"
+	// cookieNonceLen 加密前在明文前加上随机字节，相同的值每次加密结果不同
+	cookieNonceLen = 16
+)
+
+// CookieOptions SetSecureCookie 的选项
+type CookieOptions struct {
+	MaxAge   int //秒，大于0时过期时间同时写入签名的内容中；小于0时删除 cookie
+	Path     string
+	Domain   string
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite //为0时使用 c.SetSameSite 或 Engine.CookieSameSite 的值
+	Encrypt  bool          //是否使用 AES 加密，不加密时客户端可以看到内容，但不能修改
+}
+
+// SetSameSite 设置本次请求中 SetCookie 使用的 SameSite
+//		c.SetSameSite(http.SameSiteStrictMode)
+//		c.SetCookie("lang", "zh", 3600, "/", "", true, true)
+func (c *Context) SetSameSite(sameSite http.SameSite) {
+	c.sameSite = sameSite
+}
+
+// cookieSameSite 返回 c.SetSameSite 或 Engine.CookieSameSite 的值
+func (c *Context) cookieSameSite() http.SameSite {
+	if c.sameSite != 0 {
+		return c.sameSite
+	}
+	if c.engine.CookieSameSite != 0 {
+		return c.engine.CookieSameSite
+	}
+	return http.SameSiteDefaultMode
+}
+
+// SetSecureCookie 设置签名的 cookie，客户端修改后 GetSecureCookie 无法读取
+//	使用 Engine.SecretKeys 中的第一个 key 签名(和加密)，过期时间保存在签名的内容中
+//		c.SetSecureCookie("cart_id", "10086", gow.CookieOptions{MaxAge: 7 * 86400, HttpOnly: true})
+//		c.SetSecureCookie("login_channel", "wechat", gow.CookieOptions{MaxAge: 3600, Encrypt: true})
+func (c *Context) SetSecureCookie(key, value string, opts CookieOptions) {
+	path := opts.Path
+	if path == "" {
+		path = "/"
+	}
+	sameSite := opts.SameSite
+	if sameSite == 0 {
+		sameSite = c.cookieSameSite()
+	}
+	ck := &http.Cookie{
+		Name:     key,
+		MaxAge:   opts.MaxAge,
+		Path:     path,
+		Domain:   opts.Domain,
+		Secure:   opts.Secure,
+		HttpOnly: opts.HttpOnly,
+		SameSite: sameSite,
+	}
+	if opts.MaxAge >= 0 {
+		var expires int64
+		if opts.MaxAge > 0 {
+			expires = time.Now().Unix() + int64(opts.MaxAge)
+		}
+		ck.Value = c.engine.encodeCookie(key, value, expires, opts.Encrypt)
+	}
+	http.SetCookie(c.Writer, ck)
+}
+
+// GetSecureCookie 读取 SetSecureCookie 设置的 cookie
+//	签名不正确、已过期或解密失败时返回 false
+//	依次使用 Engine.SecretKeys 校验，更换 key 后旧的 cookie 仍然可以读取
+func (c *Context) GetSecureCookie(key string) (string, bool) {
+	ck, err := c.Req.Cookie(key)
+	if err != nil {
+		return "", false
+	}
+	return c.engine.decodeCookie(key, ck.Value)
+}
+
+// encodeCookie 格式为 签名(base64(类型 过期时间|值))
+//	加密时，值为 AESEncrypt(随机字节+值)
+func (engine *Engine) encodeCookie(name, value string, expires int64, encrypt bool) string {
+	typ := cookiePlain
+	if encrypt {
+		typ = cookieEncrypted
+		nonce := make([]byte, cookieNonceLen)
+		rand.Read(nonce)
+		value = util.AESEncrypt(string(nonce)+value, aesKey(engine.secretKeys()[0]))
+	}
+	return engine.signValue(name, typ+strconv.FormatInt(expires, 10)+"|"+value)
+}
+
+// decodeCookie 校验签名和过期时间，加密时解密
+func (engine *Engine) decodeCookie(name, signed string) (string, bool) {
+	payload, key, ok := engine.verify(name, signed)
+	if !ok || len(payload) < 1 {
+		return "", false
+	}
+	typ, payload := payload[:1], payload[1:]
+	i := strings.IndexByte(payload, '|')
+	if i < 0 {
+		return "", false
+	}
+	expires, err := strconv.ParseInt(payload[:i], 10, 64)
+	if err != nil || (expires > 0 && time.Now().Unix() > expires) {
+		return "", false
+	}
+	value := payload[i+1:]
+	switch typ {
+	case cookiePlain:
+		return value, true
+	case cookieEncrypted:
+		plain, err := util.AESDecrypt(value, aesKey(key))
+		if err != nil || len(plain) < cookieNonceLen {
+			return "", false
+		}
+		return plain[cookieNonceLen:], true
+	}
+	return "", false
+}
+
+// aesKey 从 secret 派生 AES-256 的 key，与签名使用的 key 不同
+func aesKey(secret string) string {
+	sum := sha256.Sum256([]byte("gow-cookie-aes|" + secret))
+	return string(sum[:])
+}
+
+// signValue 使用 SecretKeys 中的第一个签名
+//	格式为 base64(value).signature
+func (engine *Engine) signValue(name, value string) string {
+	data := base64.RawURLEncoding.EncodeToString([]byte(value))
+	return data + "." + sign(engine.secretKeys()[0], name, data)
+}
+
+// verifyValue 校验签名，返回原值
+func (engine *Engine) verifyValue(name, signed string) (string, bool) {
+	value, _, ok := engine.verify(name, signed)
+	return value, ok
+}
+
+// verify 校验签名，返回原值和签名使用的 key
+//	依次使用 SecretKeys 校验，以支持更换 key
+func (engine *Engine) verify(name, signed string) (string, string, bool) {
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", "", false
+	}
+	data, sig := signed[:i], signed[i+1:]
+	for _, key := range engine.secretKeys() {
+		if hmac.Equal([]byte(sig), []byte(sign(key, name, data))) {
+			b, err := base64.RawURLEncoding.DecodeString(data)
+			if err != nil {
+				return "", "", false
+			}
+			return string(b), key, true
+		}
+	}
+	return "", "", false
+}
+
+// secretKeys 没有设置 SecretKeys 时，使用进程内随机生成的 key
+//	重启或多实例部署时签名会失效，生产环境请配置 secret_keys
+func (engine *Engine) secretKeys() []string {
+	if len(engine.SecretKeys) > 0 {
+		return engine.SecretKeys
+	}
+	randomSecretOnce.Do(func() {
+		b := make([]byte, 32)
+		rand.Read(b)
+		randomSecret = string(b)
+		debugPrint("[WARNING] SecretKeys is empty, using a random key")
+	})
+	return []string{randomSecret}
+}
+
+// sign HMAC-SHA256
+func sign(key, name, data string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(name + "|" + data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
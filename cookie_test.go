@@ -0,0 +1,84 @@
+package gow
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecureCookie(t *testing.T) {
+	expires := time.Now().Unix() + 3600
+	tests := []struct {
+		name    string
+		encrypt bool
+		modify  func(e *Engine, signed string) (string, string)
+		want    bool
+	}{
+		{"plain", false, nil, true},
+		{"encrypted", true, nil, true},
+		{"tampered value", false, func(e *Engine, s string) (string, string) {
+			return "cart", "X" + s[1:]
+		}, false},
+		{"tampered signature", true, func(e *Engine, s string) (string, string) {
+			return "cart", s[:len(s)-1] + "A"
+		}, false},
+		{"other cookie name", false, func(e *Engine, s string) (string, string) {
+			return "admin", s
+		}, false},
+		{"rotated key", true, func(e *Engine, s string) (string, string) {
+			e.SecretKeys = []string{"new-key", "old-key"}
+			return "cart", s
+		}, true},
+		{"removed key", false, func(e *Engine, s string) (string, string) {
+			e.SecretKeys = []string{"new-key"}
+			return "cart", s
+		}, false},
+		{"expired", false, func(e *Engine, s string) (string, string) {
+			return "cart", e.encodeCookie("cart", "10086", time.Now().Unix()-1, false)
+		}, false},
+	}
+	for _, tt := range tests {
+		e := New()
+		e.SecretKeys = []string{"old-key"}
+		name, signed := "cart", e.encodeCookie("cart", "10086", expires, tt.encrypt)
+		if tt.encrypt && strings.Contains(signed, "10086") {
+			t.Errorf("%s: encrypted cookie contains the plain value", tt.name)
+		}
+		if tt.modify != nil {
+			name, signed = tt.modify(e, signed)
+		}
+		v, ok := e.decodeCookie(name, signed)
+		if ok != tt.want || (ok && v != "10086") {
+			t.Errorf("%s: decodeCookie() = %q, %v, want ok=%v", tt.name, v, ok, tt.want)
+		}
+	}
+}
+
+func TestSecureCookie_Context(t *testing.T) {
+	r := New()
+	r.SecretKeys = []string{"key"}
+	r.GET("/set", func(c *Context) {
+		c.SetSecureCookie("uid", "10", CookieOptions{MaxAge: 60, HttpOnly: true, Encrypt: true})
+	})
+	r.GET("/get", func(c *Context) {
+		v, ok := c.GetSecureCookie("uid")
+		if !ok {
+			v = "invalid"
+		}
+		c.String(v)
+	})
+
+	w := performRequest(r, "GET", "/set", nil, nil)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].MaxAge != 60 {
+		t.Fatalf("unexpected cookies %v", cookies)
+	}
+	w = performRequest(r, "GET", "/get", nil, map[string]string{"Cookie": "uid=" + cookies[0].Value})
+	if got := w.Body.String(); got != "10" {
+		t.Errorf("GetSecureCookie() = %q, want 10", got)
+	}
+	w = performRequest(r, "GET", "/get", nil, map[string]string{"Cookie": "uid=10"})
+	if got := w.Body.String(); got != "invalid" {
+		t.Errorf("unsigned cookie: GetSecureCookie() = %q, want invalid", got)
+	}
+}
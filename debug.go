@@ -2,6 +2,7 @@ package gow
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 )
 
@@ -23,6 +24,13 @@ func debugPrintError(err error) {
 	fmt.Fprintf(os.Stdout, "[debugError] %v \n", err)
 }
 
+// debugPrintServeError Run 等方法返回时使用，平滑关闭(nil 或 http.ErrServerClosed)时不打印
+func debugPrintServeError(err error) {
+	if err != nil && err != http.ErrServerClosed {
+		debugPrintError(err)
+	}
+}
+
 // debugPrint
 func debugPrint(format string, v ...interface{}) {
 	fmt.Fprintf(os.Stdout, "[debugPrint] "+format+"\n", v...)
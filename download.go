@@ -0,0 +1,136 @@
+package gow
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Attachment 以附件的方式下载文件，filename 为客户端保存的文件名
+//	支持 Range(断点续传、多段)、If-Range、If-None-Match、If-Modified-Since
+//	filename 为空时使用文件名；中文等非 ASCII 文件名使用 RFC 5987 编码
+//		c.Attachment("./export/2020-07.xlsx", "7月订单.xlsx")
+func (c *Context) Attachment(filePath, filename string) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		c.fileError(err)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		c.fileError(err)
+		return
+	}
+	if fi.IsDir() {
+		c.fileError(os.ErrNotExist)
+		return
+	}
+	if filename == "" {
+		filename = fi.Name()
+	}
+	if c.Writer.Header().Get("ETag") == "" {
+		c.SetHeader("ETag", fmt.Sprintf(`"%x-%x"`, fi.ModTime().UnixNano(), fi.Size()))
+	}
+	c.serveContent(f, filename, fi.ModTime())
+}
+
+// DownloadReader 以附件的方式下载 r 中的内容
+//	支持 Range 和条件请求；modTime 为零值时不设置 Last-Modified，If-Range 需要调用方设置 ETag
+//		f, _ := os.Open(path)
+//		defer f.Close()
+//		c.DownloadReader(f, "报表.csv", time.Now())
+func (c *Context) DownloadReader(r io.ReadSeeker, name string, modTime time.Time) {
+	if !modTime.IsZero() && c.Writer.Header().Get("ETag") == "" {
+		if size, err := r.Seek(0, io.SeekEnd); err == nil {
+			if _, err = r.Seek(0, io.SeekStart); err == nil {
+				c.SetHeader("ETag", fmt.Sprintf(`"%x-%x"`, modTime.UnixNano(), size))
+			}
+		}
+	}
+	c.serveContent(r, name, modTime)
+}
+
+// serveContent 使用 http.ServeContent 处理 Range 和条件请求
+//	Content-Type 为空时根据 name 的扩展名设置
+func (c *Context) serveContent(r io.ReadSeeker, name string, modTime time.Time) {
+	c.SetHeader("Content-Disposition", ContentDisposition("attachment", name))
+	if c.Writer.Header().Get("Content-Type") == "" && filepath.Ext(name) == "" {
+		c.SetHeader("Content-Type", "application/octet-stream")
+	}
+	http.ServeContent(c.Writer, c.Req, name, modTime, r)
+	c.StatusCode = c.Writer.Status()
+}
+
+// fileError 打开文件失败时的响应
+func (c *Context) fileError(err error) {
+	switch {
+	case os.IsNotExist(err):
+		c.AbortWithError(ErrNotFound.Wrap(err))
+	case os.IsPermission(err):
+		c.AbortWithError(ErrForbidden.Wrap(err))
+	default:
+		c.AbortWithError(ErrInternal.Wrap(err))
+	}
+}
+
+// ContentDisposition 返回 Content-Disposition 的值
+//	filename 为 ASCII 兼容的文件名，filename* 为 RFC 5987 编码的 UTF-8 文件名
+//		ContentDisposition("attachment", "报表.xlsx")
+//		// attachment; filename="__.xlsx"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.xlsx
+func ContentDisposition(dispType, filename string) string {
+	if filename == "" {
+		return dispType
+	}
+	filename = filepath.Base(filename)
+	fallback, ascii := asciiFilename(filename)
+	if ascii {
+		return fmt.Sprintf(`%s; filename="%s"`, dispType, fallback)
+	}
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, dispType, fallback, encodeRFC5987(filename))
+}
+
+// asciiFilename 把非 ASCII 和引号、反斜杠、控制字符替换为 _
+//	第二个返回值表示 filename 是否不需要替换
+func asciiFilename(filename string) (string, bool) {
+	ascii := true
+	var b strings.Builder
+	for _, r := range filename {
+		if r < 0x20 || r >= 0x7f || r == '"' || r == '\\' {
+			b.WriteByte('_')
+			ascii = false
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), ascii
+}
+
+// encodeRFC5987 按 RFC 5987 的 attr-char 编码
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if isAttrChar(ch) {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[ch>>4])
+		b.WriteByte(hex[ch&0x0f])
+	}
+	return b.String()
+}
+
+func isAttrChar(ch byte) bool {
+	switch {
+	case 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", ch) >= 0
+}
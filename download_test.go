@@ -0,0 +1,108 @@
+package gow
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"", "attachment"},
+		{"report.csv", `attachment; filename="report.csv"`},
+		{"../../etc/passwd", `attachment; filename="passwd"`},
+		{"报表.xlsx", `attachment; filename="__.xlsx"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.xlsx`},
+		{`a"b\c.txt`, `attachment; filename="a_b_c.txt"; filename*=UTF-8''a%22b%5Cc.txt`},
+		{"a b.txt", `attachment; filename="a b.txt"`},
+		{"a\r\nb.txt", `attachment; filename="a__b.txt"; filename*=UTF-8''a%0D%0Ab.txt`},
+	}
+	for _, tt := range tests {
+		got := ContentDisposition("attachment", tt.filename)
+		if got != tt.want {
+			t.Errorf("ContentDisposition(%q) = %s, want %s", tt.filename, got, tt.want)
+		}
+	}
+
+	// 标准库可以解析出原始的文件名
+	_, params, err := mime.ParseMediaType(ContentDisposition("attachment", "7月订单.xlsx"))
+	if err != nil || params["filename"] != "7月订单.xlsx" {
+		t.Errorf("ParseMediaType got %v, %v", params, err)
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gow-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	content := []byte("0123456789abcdefghij")
+	path := filepath.Join(dir, "data.bin")
+	ioutil.WriteFile(path, content, 0644)
+
+	r := New()
+	r.GET("/file", func(c *Context) {
+		c.Attachment(path, "订单.bin")
+	})
+	r.GET("/missing", func(c *Context) {
+		c.Attachment(filepath.Join(dir, "none"), "")
+	})
+	r.GET("/reader", func(c *Context) {
+		c.DownloadReader(bytes.NewReader(content), "report.csv", time.Now())
+	})
+
+	w := performRequest(r, "GET", "/file", nil, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), content) || etag == "" ||
+		w.Header().Get("Accept-Ranges") != "bytes" || w.Header().Get("Content-Type") != "application/octet-stream" ||
+		!strings.Contains(w.Header().Get("Content-Disposition"), "filename*=UTF-8''%E8%AE%A2%E5%8D%95.bin") {
+		t.Fatalf("full download: %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+		body    string
+	}{
+		{"range", map[string]string{"Range": "bytes=10-14"}, http.StatusPartialContent, "abcde"},
+		{"suffix range", map[string]string{"Range": "bytes=-3"}, http.StatusPartialContent, "hij"},
+		{"open range", map[string]string{"Range": "bytes=15-"}, http.StatusPartialContent, "fghij"},
+		{"invalid range", map[string]string{"Range": "bytes=100-"}, http.StatusRequestedRangeNotSatisfiable, ""},
+		// If-Range 匹配时返回部分内容，不匹配时返回全部
+		{"if-range", map[string]string{"Range": "bytes=0-1", "If-Range": etag}, http.StatusPartialContent, "01"},
+		{"if-range changed", map[string]string{"Range": "bytes=0-1", "If-Range": `"old"`}, http.StatusOK, string(content)},
+		{"if-none-match", map[string]string{"If-None-Match": etag}, http.StatusNotModified, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, "GET", "/file", nil, tt.headers)
+		if w.Code != tt.code || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+
+	// 多段 Range
+	w = performRequest(r, "GET", "/file", nil, map[string]string{"Range": "bytes=0-1,18-19"})
+	if mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); w.Code != http.StatusPartialContent || mediaType != "multipart/byteranges" {
+		t.Errorf("multi range: got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	if w = performRequest(r, "GET", "/missing", nil, map[string]string{"Accept-Language": "en"}); w.Code != http.StatusNotFound {
+		t.Errorf("missing file: got %d", w.Code)
+	}
+
+	w = performRequest(r, "GET", "/reader", nil, map[string]string{"Range": "bytes=0-3"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "0123" || w.Header().Get("ETag") == "" ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") || w.Header().Get("Content-Disposition") != `attachment; filename="report.csv"` {
+		t.Errorf("reader: got %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+}
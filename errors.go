@@ -0,0 +1,278 @@
+package gow
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gkzy/gow/lib/logy"
+	"github.com/gkzy/gow/render"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HeaderRequestID 请求 id 的 header
+const HeaderRequestID = "X-Request-Id"
+
+// BizError 业务错误，包含 http 状态码、业务码和多语言消息
+//	使用 RegisterError 注册，在 handler 中使用 c.Error 或 c.AbortWithError 返回
+//		var ErrUserNotFound = gow.RegisterError(10404, http.StatusNotFound, map[string]string{
+//			"zh": "用户不存在",
+//			"en": "user not found",
+//		})
+//
+//		user, err := findUser(id)
+//		if err != nil {
+//			c.Error(ErrUserNotFound.Wrap(err))
+//			return
+//		}
+type BizError struct {
+	Code     int               // 业务码
+	Status   int               // http 状态码
+	Messages map[string]string // 语言 => 消息
+	Data     interface{}       // 返回给客户端的数据，如字段校验错误
+	Err      error             // 原始错误，只记录日志，不返回给客户端
+}
+
+var (
+	bizErrorsMu sync.RWMutex
+	bizErrors   = make(map[int]*BizError)
+)
+
+// 内置的错误码，与 http 状态码相同
+var (
+	ErrBadRequest         = RegisterError(http.StatusBadRequest, http.StatusBadRequest, map[string]string{"zh": "请求参数错误", "en": "bad request"})
+	ErrUnauthorized       = RegisterError(http.StatusUnauthorized, http.StatusUnauthorized, map[string]string{"zh": "请先登录", "en": "unauthorized"})
+	ErrForbidden          = RegisterError(http.StatusForbidden, http.StatusForbidden, map[string]string{"zh": "没有权限", "en": "forbidden"})
+	ErrNotFound           = RegisterError(http.StatusNotFound, http.StatusNotFound, map[string]string{"zh": "资源不存在", "en": "not found"})
+	ErrNotAcceptable      = RegisterError(http.StatusNotAcceptable, http.StatusNotAcceptable, map[string]string{"zh": "不支持请求的响应格式", "en": "not acceptable"})
+	ErrInternal           = RegisterError(http.StatusInternalServerError, http.StatusInternalServerError, map[string]string{"zh": "服务器内部错误", "en": "internal server error"})
+	ErrServiceUnavailable = RegisterError(http.StatusServiceUnavailable, http.StatusServiceUnavailable, map[string]string{"zh": "服务暂时不可用，请稍后重试", "en": "service unavailable"})
+	ErrGatewayTimeout     = RegisterError(http.StatusGatewayTimeout, http.StatusGatewayTimeout, map[string]string{"zh": "请求超时", "en": "gateway timeout"})
+)
+
+// RegisterError 注册错误码，code 重复时 panic
+func RegisterError(code, status int, messages map[string]string) *BizError {
+	bizErrorsMu.Lock()
+	defer bizErrorsMu.Unlock()
+	if _, ok := bizErrors[code]; ok {
+		panic(fmt.Sprintf("gow: error code %d is already registered", code))
+	}
+	e := &BizError{Code: code, Status: status, Messages: messages}
+	bizErrors[code] = e
+	return e
+}
+
+// LookupError 按业务码查找注册的错误
+func LookupError(code int) (*BizError, bool) {
+	bizErrorsMu.RLock()
+	defer bizErrorsMu.RUnlock()
+	e, ok := bizErrors[code]
+	return e, ok
+}
+
+func (e *BizError) Error() string {
+	msg := fmt.Sprintf("code=%d status=%d msg=%s", e.Code, e.Status, e.Message("en"))
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap Unwrap
+func (e *BizError) Unwrap() error {
+	return e.Err
+}
+
+// Is 业务码相同时为同一个错误
+//		errors.Is(err, ErrUserNotFound)
+func (e *BizError) Is(target error) bool {
+	t, ok := target.(*BizError)
+	return ok && t.Code == e.Code
+}
+
+// Wrap 返回带有原始错误的副本
+func (e *BizError) Wrap(err error) *BizError {
+	n := *e
+	n.Err = err
+	return &n
+}
+
+// WithData 返回带有 data 的副本
+//		c.Error(gow.ErrBadRequest.WithData(errs.Translate("zh")))
+func (e *BizError) WithData(data interface{}) *BizError {
+	n := *e
+	n.Data = data
+	return &n
+}
+
+// Message 返回 lang 的消息
+//	依次使用 lang、lang 的主语言(zh-CN 使用 zh)、en 和任意一个消息
+func (e *BizError) Message(lang string) string {
+	lang = strings.ToLower(lang)
+	if msg, ok := e.Messages[lang]; ok {
+		return msg
+	}
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		if msg, ok := e.Messages[lang[:i]]; ok {
+			return msg
+		}
+	}
+	if msg, ok := e.Messages["en"]; ok {
+		return msg
+	}
+	for _, msg := range e.Messages {
+		return msg
+	}
+	return http.StatusText(e.Status)
+}
+
+// Response 统一的 json 响应
+type Response struct {
+	Code      int         `json:"code"`
+	Msg       string      `json:"msg"`
+	Data      interface{} `json:"data"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Error 把 err 记录到 c.Errors，由 ErrorHandler 返回响应并记录日志
+//	不会停止执行后续的 handler，在中间件中使用 AbortWithError
+func (c *Context) Error(err error) {
+	if err == nil {
+		return
+	}
+	c.Errors = append(c.Errors, err)
+}
+
+// AbortWithError 记录 err，立即返回错误响应，并停止执行后续的 handler
+func (c *Context) AbortWithError(err error) {
+	c.Error(err)
+	c.writeError(err)
+	c.StopRun()
+}
+
+// Success 返回统一格式的成功响应
+//		c.Success(gow.H{"id": 1}) // {"code":0,"msg":"ok","data":{"id":1},"request_id":"..."}
+func (c *Context) Success(data interface{}) {
+	c.ServerJSON(http.StatusOK, Response{Msg: "ok", Data: data, RequestID: c.RequestID()})
+}
+
+// RequestID 返回请求 id
+//	使用请求中的 X-Request-Id，没有时生成一个，并写入响应头
+func (c *Context) RequestID() string {
+	if c.requestID != "" {
+		return c.requestID
+	}
+	id := c.GetHeader(HeaderRequestID)
+	if id == "" || len(id) > 128 {
+		b := make([]byte, 16)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	c.requestID = id
+	c.Writer.Header().Set(HeaderRequestID, id)
+	return id
+}
+
+// ErrorHandler 错误处理中间件，Default() 中已使用
+//	handler 结束后，使用最后一个错误返回响应：
+//	BizError 使用注册的状态码、业务码和消息，其他错误返回 ErrInternal，不会泄露错误内容
+//	浏览器请求返回 html 错误页面(见 Engine.ErrorPage)，其他请求返回 Response
+//	所有错误使用 lib/logy 记录日志，日志中包含 request id
+func ErrorHandler() HandlerFunc {
+	return func(c *Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		if !c.Writer.Written() {
+			c.writeError(c.Errors[len(c.Errors)-1])
+		}
+		c.logErrors()
+	}
+}
+
+// toBizError err 不是 BizError 时，使用 ErrInternal 包装
+func toBizError(err error) *BizError {
+	var be *BizError
+	if errors.As(err, &be) {
+		return be
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// writeError 返回错误响应
+func (c *Context) writeError(err error) {
+	if c.Writer.Written() {
+		return
+	}
+	be := toBizError(err)
+	msg := be.Message(c.Lang())
+	if be.Status >= http.StatusInternalServerError && c.engine.RunMode == devMode && be.Err != nil {
+		msg += ": " + be.Err.Error()
+	}
+
+	if c.NegotiateFormat(MIMEJSON, MIMEHTML) == MIMEHTML {
+		c.writeErrorPage(be, msg)
+		return
+	}
+	c.ServerJSON(be.Status, Response{
+		Code:      be.Code,
+		Msg:       msg,
+		Data:      be.Data,
+		RequestID: c.RequestID(),
+	})
+}
+
+var errorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Status}} {{.Msg}}</title></head>
+<body>
+<h1>{{.Status}}</h1>
+<p>{{.Msg}}</p>
+<p><small>request id: {{.RequestID}}</small></p>
+</body>
+</html>
+`))
+
+// writeErrorPage 返回 html 错误页面
+//	设置了 Engine.ErrorPage 且开启 AutoRender 时使用该模板，模板中可以使用 .error.Msg .error.RequestID 等
+func (c *Context) writeErrorPage(be *BizError, msg string) {
+	data := map[string]interface{}{
+		"Status":    be.Status,
+		"Code":      be.Code,
+		"Msg":       msg,
+		"Data":      be.Data,
+		"RequestID": c.RequestID(),
+	}
+	c.Status(be.Status)
+	if c.engine.ErrorPage != "" && c.engine.AutoRender {
+		c.Data["error"] = data
+		r := render.HTMLRender{}.Instance(c.engine.viewsPath, c.engine.ErrorPage, c.engine.FuncMap, c.engine.delims, c.engine.AutoRender, c.engine.RunMode, c.Data)
+		err := r.Render(c.Writer)
+		if err == nil {
+			return
+		}
+		c.Error(err)
+		if c.Writer.Written() {
+			return
+		}
+		// 模板渲染失败时使用内置页面
+	}
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	errorPageTemplate.Execute(c.Writer, data)
+}
+
+// logErrors 记录所有错误，状态码大于等于 500 时使用 error 级别
+func (c *Context) logErrors() {
+	for _, err := range c.Errors {
+		be := toBizError(err)
+		lvl := logy.Lwarn
+		if be.Status >= http.StatusInternalServerError {
+			lvl = logy.Lerror
+		}
+		logy.Std.Output(c.RequestID(), lvl, 2, fmt.Sprintf("%s %s | %d | %v", c.Req.Method, c.Req.URL.Path, c.Writer.Status(), err))
+	}
+}
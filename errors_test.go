@@ -0,0 +1,138 @@
+package gow
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var errTestUserNotFound = RegisterError(10404, http.StatusNotFound, map[string]string{"zh": "用户不存在", "en": "user not found"})
+
+func TestErrorHandler(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/biz", func(c *Context) {
+		c.Error(errTestUserNotFound.Wrap(errors.New("sql: no rows")))
+	})
+	r.GET("/internal", func(c *Context) {
+		c.Error(errors.New("dial tcp: connection refused"))
+	})
+	r.GET("/data", func(c *Context) {
+		c.Error(ErrBadRequest.WithData(H{"mobile": "invalid"}))
+	})
+	r.GET("/fail", func(c *Context) {
+		c.Fail(http.StatusBadRequest, "mobile is invalid")
+	})
+	r.GET("/fail-custom", func(c *Context) {
+		c.Fail(http.StatusTooManyRequests, "slow down")
+	})
+	r.GET("/abort", func(c *Context) {
+		c.AbortWithError(ErrForbidden)
+	}, func(c *Context) {
+		c.String("next handler")
+	})
+	r.GET("/written", func(c *Context) {
+		c.String("ok")
+		c.Error(ErrInternal)
+	})
+
+	en := map[string]string{"Accept-Language": "en"}
+	tests := []struct {
+		path    string
+		headers map[string]string
+		code    int
+		resp    Response
+	}{
+		{"/biz", en, http.StatusNotFound, Response{Code: 10404, Msg: "user not found"}},
+		{"/biz", map[string]string{"Accept-Language": "zh-CN,zh;q=0.9"}, http.StatusNotFound, Response{Code: 10404, Msg: "用户不存在"}},
+		// dev 模式下，500 错误的消息包含原始错误
+		{"/internal", en, http.StatusInternalServerError, Response{Code: 500, Msg: "internal server error: dial tcp: connection refused"}},
+		{"/data", en, http.StatusBadRequest, Response{Code: 400, Msg: "bad request", Data: map[string]interface{}{"mobile": "invalid"}}},
+		{"/fail", en, http.StatusBadRequest, Response{Code: 400, Msg: "mobile is invalid"}},
+		{"/fail", map[string]string{"Accept-Language": "zh-CN"}, http.StatusBadRequest, Response{Code: 400, Msg: "mobile is invalid"}},
+		{"/fail-custom", en, http.StatusTooManyRequests, Response{Code: 429, Msg: "slow down"}},
+		{"/abort", en, http.StatusForbidden, Response{Code: 403, Msg: "forbidden"}},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, "GET", tt.path, nil, tt.headers)
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.path, w.Code, tt.code)
+		}
+		var resp Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Errorf("%s: invalid json %q", tt.path, w.Body.String())
+			continue
+		}
+		if resp.RequestID == "" || resp.RequestID != w.Header().Get(HeaderRequestID) {
+			t.Errorf("%s: request_id = %q, header %q", tt.path, resp.RequestID, w.Header().Get(HeaderRequestID))
+		}
+		resp.RequestID = ""
+		if got, _ := json.Marshal(resp); string(got) != mustJSON(tt.resp) {
+			t.Errorf("%s: response = %s, want %s", tt.path, got, mustJSON(tt.resp))
+		}
+	}
+
+	// 已写入响应时不再写入错误
+	if w := performRequest(r, "GET", "/written", nil, nil); w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("/written: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestErrorHandler_Prod(t *testing.T) {
+	r := New()
+	r.RunMode = prodMode
+	r.Use(ErrorHandler())
+	r.GET("/", func(c *Context) {
+		c.Error(errors.New("secret dsn"))
+	})
+	w := performRequest(r, "GET", "/", nil, map[string]string{"Accept-Language": "en", HeaderRequestID: "req-1"})
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("prod response leaks the error: %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"request_id":"req-1"`) || w.Header().Get(HeaderRequestID) != "req-1" {
+		t.Errorf("request id not echoed: %s", w.Body.String())
+	}
+}
+
+func TestErrorHandler_HTML(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/", func(c *Context) {
+		c.Error(errTestUserNotFound)
+	})
+	w := performRequest(r, "GET", "/", nil, map[string]string{
+		"Accept":          "text/html,application/xhtml+xml,*/*;q=0.8",
+		"Accept-Language": "en",
+	})
+	if w.Code != http.StatusNotFound || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if body := w.Body.String(); !strings.Contains(body, "<p>user not found</p>") || !strings.Contains(body, w.Header().Get(HeaderRequestID)) {
+		t.Errorf("unexpected page %s", body)
+	}
+}
+
+func TestRegisterError(t *testing.T) {
+	if be, ok := LookupError(10404); !ok || be != errTestUserNotFound {
+		t.Fatal("LookupError(10404) did not return the registered error")
+	}
+	if !errors.Is(errTestUserNotFound.Wrap(errors.New("x")), errTestUserNotFound) {
+		t.Error("wrapped error should match with errors.Is")
+	}
+	if got := errTestUserNotFound.Message("zh-TW"); got != "用户不存在" {
+		t.Errorf("Message(zh-TW) = %q", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate code should panic")
+		}
+	}()
+	RegisterError(10404, http.StatusNotFound, nil)
+}
+
+func mustJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,102 @@
+package gow
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// flashCookieName 未使用 session 时，保存 flash 的 cookie
+	flashCookieName = "gow_flash"
+	// flashDataKey c.Data 中 flash 的 key
+	flashDataKey = "flash"
+)
+
+// flashKey session 中 flash 的 key，不会与用户的 key 冲突
+type flashKey string
+
+const flashSessionKey flashKey = "flash"
+
+func init() {
+	gob.Register(flashKey(""))
+	gob.Register(map[string]string{})
+}
+
+// Flash 保存一条 flash 消息，在下一个请求中读取一次后清除
+//	使用 gow.Session() 时保存在 session 中，否则保存在签名的 cookie 中
+//	需要在写入响应之前调用
+//		c.Flash("success", "保存成功")
+//		c.Redirect(302, "/admin/user")
+func (c *Context) Flash(key, msg string) {
+	flashes := make(map[string]string)
+	for k, v := range c.loadFlashes() {
+		flashes[k] = v
+	}
+	flashes[key] = msg
+	c.saveFlashes(flashes)
+}
+
+// Flashes 返回所有 flash 消息，并清除
+//	c.HTML 会自动调用，模板中使用:
+//		<< if .flash.success >><< .flash.success >><< end >>
+func (c *Context) Flashes() map[string]string {
+	flashes := c.loadFlashes()
+	if len(flashes) > 0 {
+		c.saveFlashes(nil)
+	}
+	return flashes
+}
+
+// GetFlash 返回 key 的 flash 消息，并清除所有 flash
+func (c *Context) GetFlash(key string) string {
+	return c.Flashes()[key]
+}
+
+// loadFlashes 返回当前的 flash
+func (c *Context) loadFlashes() map[string]string {
+	if c.flashLoaded {
+		return c.flashes
+	}
+	c.flashLoaded = true
+	if c.session != nil {
+		c.flashes, _ = getSession(c, flashSessionKey).(map[string]string)
+		return c.flashes
+	}
+	ck, err := c.Req.Cookie(flashCookieName)
+	if err != nil || ck.Value == "" {
+		return nil
+	}
+	if value, ok := c.engine.verifyValue(flashCookieName, ck.Value); ok {
+		json.Unmarshal([]byte(value), &c.flashes)
+	}
+	return c.flashes
+}
+
+// saveFlashes 保存 flash，为空时清除
+func (c *Context) saveFlashes(flashes map[string]string) {
+	c.flashes = flashes
+	c.flashLoaded = true
+	if c.session != nil {
+		if len(flashes) == 0 {
+			deleteSession(c, flashSessionKey)
+			return
+		}
+		setSession(c, flashSessionKey, flashes)
+		return
+	}
+
+	ck := &http.Cookie{
+		Name:     flashCookieName,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if len(flashes) == 0 {
+		ck.MaxAge = -1
+	} else {
+		b, _ := json.Marshal(flashes)
+		ck.Value = c.engine.signValue(flashCookieName, string(b))
+	}
+	http.SetCookie(c.Writer, ck)
+}
@@ -0,0 +1,98 @@
+package gow
+
+import (
+	"encoding/json"
+	"github.com/gkzy/gow/session"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// flashRouter /save 保存 flash 后重定向，/show 返回读取到的 flash
+func flashRouter(middleware ...HandlerFunc) *Engine {
+	r := New()
+	r.Use(middleware...)
+	r.GET("/save", func(c *Context) {
+		c.Flash("success", "saved")
+		c.Flash("info", "hello")
+		c.Redirect(http.StatusFound, "/show")
+	})
+	r.GET("/show", func(c *Context) {
+		c.JSON(c.Flashes())
+	})
+	return r
+}
+
+// cookieJar 保存响应中的 cookie，MaxAge < 0 时删除
+type cookieJar map[string]*http.Cookie
+
+func (j cookieJar) do(r *Engine, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for _, ck := range j {
+		req.AddCookie(ck)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	for _, ck := range w.Result().Cookies() {
+		if ck.MaxAge < 0 {
+			delete(j, ck.Name)
+			continue
+		}
+		j[ck.Name] = ck
+	}
+	return w
+}
+
+func flashes(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	var m map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatalf("invalid json %q", w.Body.String())
+	}
+	return m
+}
+
+func TestFlash_Cookie(t *testing.T) {
+	r := flashRouter()
+	jar := cookieJar{}
+	if w := jar.do(r, "/save"); w.Code != http.StatusFound {
+		t.Fatalf("/save status = %d", w.Code)
+	}
+	if jar[flashCookieName] == nil {
+		t.Fatal("flash cookie was not set")
+	}
+	if m := flashes(t, jar.do(r, "/show")); m["success"] != "saved" || m["info"] != "hello" {
+		t.Fatalf("first read got %v", m)
+	}
+	// 读取一次后清除
+	if _, ok := jar[flashCookieName]; ok {
+		t.Fatal("flash cookie was not cleared after reading")
+	}
+	if m := flashes(t, jar.do(r, "/show")); len(m) != 0 {
+		t.Fatalf("second read got %v", m)
+	}
+
+	// 篡改的 cookie 被忽略
+	jar.do(r, "/save")
+	jar[flashCookieName] = &http.Cookie{Name: flashCookieName, Value: "x" + jar[flashCookieName].Value}
+	if m := flashes(t, jar.do(r, "/show")); len(m) != 0 {
+		t.Fatalf("tampered cookie got %v", m)
+	}
+}
+
+func TestFlash_Session(t *testing.T) {
+	defer func(m *session.Manager) { sessionManager = m }(sessionManager)
+	InitSessionStore(session.NewMemoryStore(), session.DefaultOptions())
+
+	r := flashRouter(Session())
+	jar := cookieJar{}
+	jar.do(r, "/save")
+	if _, ok := jar[flashCookieName]; ok {
+		t.Fatal("flash was saved in a cookie while using session")
+	}
+	if m := flashes(t, jar.do(r, "/show")); m["success"] != "saved" || m["info"] != "hello" {
+		t.Fatalf("first read got %v", m)
+	}
+	if m := flashes(t, jar.do(r, "/show")); len(m) != 0 {
+		t.Fatalf("second read got %v", m)
+	}
+}
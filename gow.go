@@ -1,13 +1,20 @@
 package gow
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"github.com/gkzy/gow/i18n"
 	"github.com/gkzy/gow/render"
 	"html/template"
+	"net"
 	"net/http"
+	"os"
 	"path"
 	"strings"
 	"sync"
+	"time"
 )
 
 var (
@@ -60,6 +67,10 @@ type Engine struct {
 	delims     render.Delims
 	AutoRender bool //是否渲染模板
 
+	ErrorPage        string //ErrorHandler 使用的 html 错误页面模板，为空时使用内置页面
+	JSONPCallback    string //JSONP 使用的 query 参数，默认为 callback
+	SecureJSONPrefix string //SecureJSON 的前缀，默认为 while(1);
+
 	HandleMethodNotAllowed bool
 
 	UseRawPath            bool
@@ -68,6 +79,7 @@ type Engine struct {
 	RedirectTrailingSlash bool
 	RedirectFixedPath     bool
 	MaxMultipartMemory    int64
+	MaxBodySize           int64 //请求体的最大字节数，为0时不限制，分组中使用 BodyLimit 覆盖
 
 	//views template directory
 	viewsPath   string
@@ -82,6 +94,54 @@ type Engine struct {
 
 	// session switch
 	SessionOn bool
+
+	// SecretKeys 用于签名和加密 cookie(如 flash、SetSecureCookie)
+	//	第一个用于签名，全部用于校验；更换 key 时，把新 key 放在最前面
+	SecretKeys []string
+
+	// CookieSameSite SetCookie 和 SetSecureCookie 默认的 SameSite
+	CookieSameSite http.SameSite
+
+	// LangParam 切换语言的 query 参数和 cookie，默认为 lang，见 c.Lang
+	LangParam string
+
+	// trustedProxies 可信的代理，见 SetTrustedProxies
+	trustedProxies []*net.IPNet
+
+	// http.Server
+	//	为0时不限制，RunMode 为 prod 时 ReadHeaderTimeout IdleTimeout MaxHeaderBytes 使用默认值
+	ReadTimeout       time.Duration //读取整个请求(包括body)的超时时间，会中断慢速上传，默认不限制
+	ReadHeaderTimeout time.Duration //读取请求头的超时时间
+	WriteTimeout      time.Duration //写入响应的超时时间，会中断 SSE 和大文件下载，默认不限制
+	IdleTimeout       time.Duration //keep-alive 连接的空闲时间
+	MaxHeaderBytes    int           //请求头的最大字节数
+
+	// H2COn 开启 h2c (HTTP/2 cleartext)
+	//	不使用 TLS 时，同时支持 HTTP/1.1 和 HTTP/2
+	H2COn bool
+
+	// graceful shutdown
+	GracefulOn      bool          //收到 SIGINT/SIGTERM 时平滑关闭
+	ShutdownTimeout time.Duration //平滑关闭时等待请求完成的最长时间
+	HotRestartOn    bool          //收到 SIGHUP 时热重启
+
+	mu            sync.Mutex
+	servers       []*http.Server
+	h2cConns      h2cConns
+	listeners     []*engineListener
+	shutting      bool
+	restarting    bool
+	certs         []*CertReloader
+	clientCAs     *x509.CertPool
+	clientAuth    tls.ClientAuthType
+	grpcServer    GRPCServer
+	startHooks    []func() error
+	shutdownHooks []func() error
+	startOnce     sync.Once
+	startErr      error
+	shutdownOnce  sync.Once
+	shutdownErr   error
+	done          chan struct{}
 }
 
 func New() *Engine {
@@ -94,6 +154,9 @@ func New() *Engine {
 		FuncMap:                template.FuncMap{},
 		delims:                 render.Delims{Left: "{{", Right: "}}"},
 		AutoRender:             false,
+		JSONPCallback:          "callback",
+		SecureJSONPrefix:       "while(1);",
+		LangParam:              defaultLangParam,
 		RedirectTrailingSlash:  true,
 		RedirectFixedPath:      false,
 		HandleMethodNotAllowed: false,
@@ -108,8 +171,11 @@ func New() *Engine {
 		httpAddr:               ":8080", //default http Addr
 		RunMode:                defaultMode,
 		AppPath:                getCurrentDirectory(),
+		ShutdownTimeout:        defaultShutdownTimeout,
+		done:                   make(chan struct{}),
 	}
 	engine.RouterGroup.engine = engine
+	engine.AddFuncMap("i18n", i18n.Tr)
 	engine.pool.New = func() interface{} {
 		ctx := &Context{engine: engine}
 		return ctx
@@ -121,10 +187,12 @@ func New() *Engine {
 // Default get default engine
 //	use Recovery()
 //	use Logger()
+//	use ErrorHandler()
 func Default() *Engine {
 	engine := New()
 	engine.Use(Recovery())
 	engine.Use(Logger())
+	engine.Use(ErrorHandler())
 	return engine
 }
 
@@ -141,6 +209,7 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c.responseWriter.reset(w)
 	c.Req = req
 	c.reset()
+	c.wrapBody()
 	c.Data = make(map[interface{}]interface{},0)
 	engine.handleHTTPRequest(c)
 	engine.pool.Put(c)
@@ -157,51 +226,201 @@ func (engine *Engine) SetAppConfig(app *AppConfig) {
 		engine.delims = render.Delims{Left: app.TemplateLeft, Right: app.TemplateRight}
 		engine.AutoRender = app.AutoRender
 		engine.httpAddr = app.HttpAddr
+		engine.ReadTimeout = app.ReadTimeout
+		engine.ReadHeaderTimeout = app.ReadHeaderTimeout
+		engine.WriteTimeout = app.WriteTimeout
+		engine.IdleTimeout = app.IdleTimeout
+		engine.MaxHeaderBytes = app.MaxHeaderBytes
+		engine.MaxBodySize = app.MaxBodySize
+		engine.H2COn = app.H2COn
+		if len(app.SecretKeys) > 0 {
+			engine.SecretKeys = app.SecretKeys
+		}
+		engine.CookieSameSite = app.CookieSameSite
+		if len(app.TrustedProxies) > 0 {
+			if err := engine.SetTrustedProxies(app.TrustedProxies...); err != nil {
+				debugPrintError(err)
+			}
+		}
+		if app.DefaultLang != "" {
+			i18n.SetDefaultLang(app.DefaultLang)
+		}
+		if app.LocaleDir != "" {
+			if err := i18n.Default.Load(app.LocaleDir); err != nil {
+				debugPrintError(err)
+			}
+		}
+		if app.LangParam != "" {
+			engine.LangParam = app.LangParam
+		}
 	}
 }
 
 // Run
 func (engine *Engine) Run(addr ...string) (err error) {
 	defer func() {
-		debugPrintError(err)
+		debugPrintServeError(err)
 	}()
 
-	if engine.AutoRender {
-		//builder template
-		err = render.AddViewPath(engine.viewsPath)
+	if err = engine.prepare(); err != nil {
+		return
 	}
-
 	address := engine.resolveAddress(addr)
-
-	if engine.RunMode == devMode {
-		fmt.Println(logo)
-		debugPrint("package: %s", pkg)
-		debugPrint("website: %s", site)
+	listener, err := engine.listen("tcp", address)
+	if err != nil {
+		return
 	}
-
 	debugPrint("[%s] [%s] Listening and serving HTTP on %s", engine.AppName, engine.RunMode, address)
-	err = http.ListenAndServe(address, engine)
+	srv := engine.newServer(address, nil)
+	err = engine.serve(srv, func() error {
+		return srv.Serve(listener)
+	})
 	return
 }
 
 // RunTLS
+//	证书文件变化后自动重新加载，多个证书及客户端证书校验见 AddCertificate SetClientCA
 func (engine *Engine) RunTLS(certFile, keyFile string, addr ...string) (err error) {
 	defer func() {
-		debugPrintError(err)
+		debugPrintServeError(err)
 	}()
 
-	if engine.AutoRender {
-		//builder template
-		err = render.AddViewPath(engine.viewsPath)
+	if err = engine.prepare(); err != nil {
+		return
 	}
 	address := engine.resolveAddress(addr)
-	if engine.RunMode == devMode {
-		fmt.Println(logo)
-		debugPrint("package: %s", pkg)
-		debugPrint("website: %s", site)
+	tlsConfig, err := engine.tlsConfig(certFile, keyFile)
+	if err != nil {
+		return
 	}
-	debugPrint("[%s] [%s] Listening and serving HTTP on %s", engine.AppName, engine.RunMode, address)
-	err = http.ListenAndServeTLS(address, certFile, keyFile, engine)
+	listener, err := engine.listen("tcp", address)
+	if err != nil {
+		return
+	}
+	debugPrint("[%s] [%s] Listening and serving HTTPS on %s", engine.AppName, engine.RunMode, address)
+	srv := engine.newServer(address, tlsConfig)
+	err = engine.serve(srv, func() error {
+		return srv.ServeTLS(listener, "", "")
+	})
+	return
+}
+
+// RunUnix 在 unix socket 上提供 HTTP 服务
+//	file 已存在且为 socket 文件时，先删除
+//		r.RunUnix("/tmp/gow.sock")
+func (engine *Engine) RunUnix(file string) (err error) {
+	defer func() {
+		debugPrintServeError(err)
+	}()
+
+	if err = engine.prepare(); err != nil {
+		return
+	}
+	listener, err := engine.listen("unix", file)
+	if err != nil {
+		return
+	}
+	defer func() {
+		// 热重启时，socket 文件由子进程继续使用
+		if !engine.isRestarting() {
+			os.Remove(file)
+		}
+	}()
+
+	debugPrint("[%s] [%s] Listening and serving HTTP on unix:%s", engine.AppName, engine.RunMode, file)
+	err = engine.serveListener(listener)
+	return
+}
+
+// RunFd 在已打开的文件描述符上提供 HTTP 服务
+//	如 systemd socket activation 传入的 fd 3
+//		r.RunFd(3)
+func (engine *Engine) RunFd(fd int) (err error) {
+	defer func() {
+		debugPrintServeError(err)
+	}()
+
+	if err = engine.prepare(); err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd@%d", fd))
+	listener, err := net.FileListener(f)
+	if err != nil {
+		return
+	}
+	f.Close()
+
+	debugPrint("[%s] [%s] Listening and serving HTTP on fd@%d", engine.AppName, engine.RunMode, fd)
+	err = engine.serveListener(listener)
+	return
+}
+
+// RunListener 在 listener 上提供 HTTP 服务
+func (engine *Engine) RunListener(listener net.Listener) (err error) {
+	defer func() {
+		debugPrintServeError(err)
+	}()
+
+	if err = engine.prepare(); err != nil {
+		return
+	}
+	debugPrint("[%s] [%s] Listening and serving HTTP on %s", engine.AppName, engine.RunMode, listener.Addr())
+	err = engine.serveListener(listener)
+	return
+}
+
+// RunHTTPAndTLS 同时在 httpAddr 上提供 HTTP 服务，在 tlsAddr 上提供 HTTPS 服务
+//	redirect 为 true 时，HTTP 请求全部跳转到 HTTPS
+//		r.RunHTTPAndTLS(":80", ":443", "cert.pem", "key.pem", true)
+func (engine *Engine) RunHTTPAndTLS(httpAddr, tlsAddr, certFile, keyFile string, redirect bool) (err error) {
+	defer func() {
+		debugPrintServeError(err)
+	}()
+
+	if err = engine.prepare(); err != nil {
+		return
+	}
+
+	tlsConfig, err := engine.tlsConfig(certFile, keyFile)
+	if err != nil {
+		return
+	}
+	httpListener, err := engine.listen("tcp", httpAddr)
+	if err != nil {
+		return
+	}
+	tlsListener, err := engine.listen("tcp", tlsAddr)
+	if err != nil {
+		httpListener.Close()
+		return
+	}
+
+	httpSrv := engine.newServer(httpAddr, nil)
+	if redirect {
+		httpSrv.Handler = redirectToTLS(tlsAddr)
+	}
+	tlsSrv := engine.newServer(tlsAddr, tlsConfig)
+
+	debugPrint("[%s] [%s] Listening and serving HTTP on %s", engine.AppName, engine.RunMode, httpAddr)
+	debugPrint("[%s] [%s] Listening and serving HTTPS on %s", engine.AppName, engine.RunMode, tlsAddr)
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- engine.serve(httpSrv, func() error {
+			return httpSrv.Serve(httpListener)
+		})
+	}()
+	go func() {
+		errCh <- engine.serve(tlsSrv, func() error {
+			return tlsSrv.ServeTLS(tlsListener, "", "")
+		})
+	}()
+
+	// 其中一个启动失败时，关闭另一个
+	if err = <-errCh; err != nil && err != http.ErrServerClosed {
+		engine.Shutdown(context.Background())
+		return
+	}
+	err = <-errCh
 	return
 }
 
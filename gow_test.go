@@ -0,0 +1,17 @@
+package gow
+
+import (
+	"io"
+	"net/http/httptest"
+)
+
+// performRequest 发送请求，body 不是 strings.Reader bytes.Reader 时没有 Content-Length
+func performRequest(r *Engine, method, path string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,65 @@
+package gow
+
+import (
+	"github.com/gkzy/gow/i18n"
+)
+
+const (
+	// defaultLangParam 切换语言的 query 参数和 cookie
+	defaultLangParam = "lang"
+	// langDataKey c.Data 中语言的 key，模板中使用 .lang
+	langDataKey = "lang"
+	// langCookieMaxAge 保存语言的 cookie 的有效期，一年
+	langCookieMaxAge = 365 * 86400
+)
+
+// I18n 切换语言的中间件
+//	请求中有 Engine.LangParam 参数(如 ?lang=en)且为已加载的语言时，保存到 cookie 中，之后的请求使用该语言
+//		i18n.InitLoad("conf/locale")
+//		r.Use(gow.I18n())
+func I18n() HandlerFunc {
+	return func(c *Context) {
+		if name := c.engine.LangParam; name != "" {
+			if lang := c.Query(name); lang != "" && i18n.Supports(lang) {
+				c.SetLang(lang)
+			}
+		}
+		c.Next()
+	}
+}
+
+// Lang 返回本次请求使用的语言
+//	依次使用 query 参数、cookie(Engine.LangParam)和 Accept-Language，匹配已加载的语言
+//	都不匹配时使用 i18n 的默认语言
+func (c *Context) Lang() string {
+	if c.lang != "" {
+		return c.lang
+	}
+	var langs []string
+	if name := c.engine.LangParam; name != "" {
+		langs = append(langs, c.Query(name))
+		if ck, err := c.Req.Cookie(name); err == nil {
+			langs = append(langs, ck.Value)
+		}
+	}
+	langs = append(langs, parseAccept(c.GetHeader("Accept-Language"))...)
+	c.lang = i18n.Match(langs...)
+	return c.lang
+}
+
+// SetLang 设置本次请求使用的语言，并保存到 cookie 中
+//	lang 不是已加载的语言时，使用相同主语言的语言或默认语言
+//		c.SetLang("en")
+func (c *Context) SetLang(lang string) {
+	c.lang = i18n.Match(lang)
+	if name := c.engine.LangParam; name != "" {
+		c.SetCookie(name, c.lang, langCookieMaxAge, "/", "", false, false)
+	}
+}
+
+// T 使用本次请求的语言翻译 key，见 i18n.Tr
+//		c.T("hello", "gow")
+//		c.T("cart_items", 3)
+func (c *Context) T(key string, args ...interface{}) string {
+	return i18n.Tr(c.Lang(), key, args...)
+}
@@ -0,0 +1,359 @@
+// Package i18n 多语言
+//	语言包为 ini 文件，文件名为语言，如 conf/locale/zh-CN.ini conf/locale/en.ini
+//	section 中的 key 使用 section::key 读取，与 lib/config 相同
+//		; conf/locale/zh-CN.ini
+//		hello = 你好，%s
+//		cart_items_other = 购物车中有%d件商品
+//
+//		[user]
+//		login = 登录
+//
+//		i18n.InitLoad("conf/locale")
+//		i18n.Tr("zh-CN", "hello", "gow") // 你好，gow
+//		i18n.Tr("zh-CN", "user::login")  // 登录
+package i18n
+
+import (
+	"fmt"
+	ini "github.com/go-ini/ini"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLang 默认语言
+const DefaultLang = "zh-CN"
+
+// Bundle 多个语言的语言包
+type Bundle struct {
+	mu          sync.RWMutex
+	messages    map[string]map[string]string // 语言 => key => 消息
+	names       map[string]string            // 小写的语言 => 文件名中的语言
+	fallbacks   map[string][]string
+	defaultLang string
+}
+
+// Default 默认的语言包，包级别的函数使用此语言包
+var Default = New()
+
+// New 创建语言包
+func New() *Bundle {
+	return &Bundle{
+		messages:    make(map[string]map[string]string),
+		names:       make(map[string]string),
+		fallbacks:   make(map[string][]string),
+		defaultLang: normalize(DefaultLang),
+	}
+}
+
+// InitLoad 读取 dir 中的所有 ini 文件，失败时 panic
+//		i18n.InitLoad("conf/locale")
+func InitLoad(dir string) {
+	if err := Default.Load(dir); err != nil {
+		panic("Failed to read locale directory：" + dir + ": " + err.Error())
+	}
+}
+
+// Load 读取 dir 中的所有 ini 文件，文件名为语言
+func (b *Bundle) Load(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".ini" {
+			continue
+		}
+		lang := strings.TrimSuffix(f.Name(), ".ini")
+		if err := b.LoadFile(lang, filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile 读取 lang 的 ini 文件，多次读取同一个语言时合并，后读取的覆盖先读取的
+//		i18n.Default.LoadFile("en", "conf/locale/en.ini")
+func (b *Bundle) LoadFile(lang, fileName string) error {
+	cfg, err := ini.Load(fileName)
+	if err != nil {
+		return err
+	}
+	msgs := make(map[string]string)
+	for _, sec := range cfg.Sections() {
+		prefix := ""
+		if sec.Name() != ini.DefaultSection {
+			prefix = sec.Name() + "::"
+		}
+		for _, key := range sec.Keys() {
+			msgs[prefix+key.Name()] = key.String()
+		}
+	}
+	b.AddMessages(lang, msgs)
+	return nil
+}
+
+// AddMessages 添加 lang 的消息
+//		i18n.AddMessages("en", map[string]string{"hello": "Hello, %s"})
+func (b *Bundle) AddMessages(lang string, msgs map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := normalize(lang)
+	m, ok := b.messages[key]
+	if !ok {
+		m = make(map[string]string, len(msgs))
+		b.messages[key] = m
+		b.names[key] = lang
+	}
+	for k, v := range msgs {
+		m[k] = v
+	}
+}
+
+// SetDefaultLang 设置默认语言，所有语言最后都回退到默认语言
+func (b *Bundle) SetDefaultLang(lang string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.defaultLang = normalize(lang)
+}
+
+// DefaultLang 返回默认语言
+func (b *Bundle) DefaultLang() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.name(b.defaultLang)
+}
+
+// SetFallback 设置 lang 的回退语言
+//	没有设置时，依次使用 lang、lang 的主语言(zh-TW 使用 zh)和默认语言
+//		i18n.SetFallback("zh-TW", "zh-HK", "zh-CN")
+func (b *Bundle) SetFallback(lang string, fallbacks ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	langs := make([]string, len(fallbacks))
+	for i, fb := range fallbacks {
+		langs[i] = normalize(fb)
+	}
+	b.fallbacks[normalize(lang)] = langs
+}
+
+// Languages 已加载的语言
+func (b *Bundle) Languages() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	langs := make([]string, 0, len(b.names))
+	for _, name := range b.names {
+		langs = append(langs, name)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Match 返回 langs 中第一个支持的语言，按顺序优先使用
+//	lang 没有语言包时使用主语言，如 zh 匹配 zh-CN；都不支持时返回默认语言
+//	没有加载语言包时返回第一个语言
+//		i18n.Match("fr", "en-US", "zh") // en
+func (b *Bundle) Match(langs ...string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.messages) == 0 {
+		for _, lang := range langs {
+			if lang = normalize(lang); lang != "" && lang != "*" {
+				return lang
+			}
+		}
+	}
+	for _, lang := range langs {
+		if l := b.match(normalize(lang)); l != "" {
+			return b.name(l)
+		}
+	}
+	return b.name(b.defaultLang)
+}
+
+// Supports 是否有 lang 或 lang 主语言的语言包
+func (b *Bundle) Supports(lang string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.match(normalize(lang)) != ""
+}
+
+// match lang 有语言包时返回 lang，否则返回相同主语言的语言包
+func (b *Bundle) match(lang string) string {
+	if lang == "" {
+		return ""
+	}
+	if _, ok := b.messages[lang]; ok {
+		return lang
+	}
+	base := primary(lang)
+	if _, ok := b.messages[base]; ok {
+		return base
+	}
+	// 默认语言优先，其余按名称排序，结果是固定的
+	if primary(b.defaultLang) == base {
+		if _, ok := b.messages[b.defaultLang]; ok {
+			return b.defaultLang
+		}
+	}
+	var ret string
+	for l := range b.messages {
+		if primary(l) == base && (ret == "" || l < ret) {
+			ret = l
+		}
+	}
+	return ret
+}
+
+// name 返回文件名中的语言，如 zh-CN
+func (b *Bundle) name(lang string) string {
+	if name, ok := b.names[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// chain lang 的回退链：lang、SetFallback 设置的语言、主语言、默认语言
+func (b *Bundle) chain(lang string) []string {
+	var langs []string
+	seen := make(map[string]bool)
+	var add func(l string)
+	add = func(l string) {
+		if l == "" || seen[l] {
+			return
+		}
+		seen[l] = true
+		langs = append(langs, l)
+		for _, fb := range b.fallbacks[l] {
+			add(fb)
+		}
+		add(primary(l))
+	}
+	add(lang)
+	add(b.defaultLang)
+	return langs
+}
+
+// Tr 返回 lang 中 key 的消息，args 不为空时使用 fmt.Sprintf 格式化
+//	第一个参数为整数且有复数形式(key_one key_other 等)时，根据 lang 的复数规则选择
+//	按回退链查找，都没有时返回 key
+//		i18n.Tr("en", "cart_items", 1) // 1 item
+//		i18n.Tr("en", "cart_items", 3) // 3 items
+func (b *Bundle) Tr(lang, key string, args ...interface{}) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	lang = normalize(lang)
+	n, plural := count(args)
+	for _, l := range b.chain(lang) {
+		msgs, ok := b.messages[l]
+		if !ok {
+			continue
+		}
+		if plural {
+			form := pluralRule(l)(n)
+			if msg, ok := msgs[key+"_"+form]; ok {
+				return format(msg, args)
+			}
+			if msg, ok := msgs[key+"_"+Other]; ok {
+				return format(msg, args)
+			}
+		}
+		if msg, ok := msgs[key]; ok {
+			return format(msg, args)
+		}
+	}
+	return key
+}
+
+// Has lang 的回退链中是否有 key
+func (b *Bundle) Has(lang, key string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, l := range b.chain(normalize(lang)) {
+		if _, ok := b.messages[l][key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// count 第一个参数为整数时返回该值
+func count(args []interface{}) (int64, bool) {
+	if len(args) == 0 || args[0] == nil {
+		return 0, false
+	}
+	v := reflect.ValueOf(args[0])
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// format 消息中没有 % 时不格式化，避免输出 %!(EXTRA ...)
+func format(msg string, args []interface{}) string {
+	if len(args) == 0 || !strings.Contains(msg, "%") {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// normalize 转为小写，使用 - 分隔，如 zh_CN 转为 zh-cn
+func normalize(lang string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(lang), "_", "-", -1))
+}
+
+// primary 主语言，如 zh-cn 返回 zh
+func primary(lang string) string {
+	if i := strings.IndexByte(lang, '-'); i > 0 {
+		return lang[:i]
+	}
+	return lang
+}
+
+// LoadFile 使用 Default 读取 lang 的 ini 文件
+func LoadFile(lang, fileName string) error {
+	return Default.LoadFile(lang, fileName)
+}
+
+// AddMessages 添加 lang 的消息到 Default
+func AddMessages(lang string, msgs map[string]string) {
+	Default.AddMessages(lang, msgs)
+}
+
+// SetDefaultLang 设置 Default 的默认语言
+func SetDefaultLang(lang string) {
+	Default.SetDefaultLang(lang)
+}
+
+// SetFallback 设置 Default 中 lang 的回退语言
+func SetFallback(lang string, fallbacks ...string) {
+	Default.SetFallback(lang, fallbacks...)
+}
+
+// Languages Default 中已加载的语言
+func Languages() []string {
+	return Default.Languages()
+}
+
+// Supports Default 是否支持 lang
+func Supports(lang string) bool {
+	return Default.Supports(lang)
+}
+
+// Match 使用 Default 匹配语言
+func Match(langs ...string) string {
+	return Default.Match(langs...)
+}
+
+// Tr 使用 Default 返回 lang 中 key 的消息
+//	可以直接注册为模板函数，gow 中已注册为 i18n
+//		<<i18n .lang "user::login">>
+func Tr(lang, key string, args ...interface{}) string {
+	return Default.Tr(lang, key, args...)
+}
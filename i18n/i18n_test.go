@@ -0,0 +1,136 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	b := New()
+	if err := b.Load("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestLoad(t *testing.T) {
+	b := newTestBundle(t)
+	if got, want := b.Languages(), []string{"en", "ru", "zh-CN"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Languages() = %v, want %v", got, want)
+	}
+	if err := b.Load("not_exist"); err == nil {
+		t.Fatal("Load(not_exist) should return an error")
+	}
+}
+
+func TestTr(t *testing.T) {
+	b := newTestBundle(t)
+	tests := []struct {
+		lang string
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"zh-CN", "hello", []interface{}{"gow"}, "你好，gow"},
+		{"en", "hello", []interface{}{"gow"}, "Hello, gow"},
+		{"en", "user::login", nil, "Sign in"},
+		{"zh_cn", "user::login", nil, "登录"},
+		{"en", "percent", nil, "100%"},
+		// 回退链
+		{"en-US", "hello", []interface{}{"gow"}, "Hello, gow"},
+		{"en", "only_zh", nil, "只有中文"},
+		{"fr", "user::login", nil, "登录"},
+		{"en", "not_exist", nil, "not_exist"},
+		// 复数
+		{"en", "cart_items", []interface{}{1}, "1 item in your cart"},
+		{"en", "cart_items", []interface{}{3}, "3 items in your cart"},
+		{"zh-CN", "cart_items", []interface{}{1}, "购物车中有1件商品"},
+		{"ru", "files", []interface{}{1}, "1 файл"},
+		{"ru", "files", []interface{}{3}, "3 файла"},
+		{"ru", "files", []interface{}{11}, "11 файлов"},
+		{"ru", "files", []interface{}{uint8(22)}, "22 файла"},
+	}
+	for _, tt := range tests {
+		if got := b.Tr(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("Tr(%q, %q, %v) = %q, want %q", tt.lang, tt.key, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestSetFallback(t *testing.T) {
+	b := newTestBundle(t)
+	b.AddMessages("zh-TW", map[string]string{"user::login": "登入"})
+	b.SetFallback("zh-HK", "zh-TW")
+	if got := b.Tr("zh-HK", "user::login"); got != "登入" {
+		t.Errorf("zh-HK = %q, want 登入", got)
+	}
+	if got := b.Tr("zh-HK", "only_zh"); got != "只有中文" {
+		t.Errorf("zh-HK only_zh = %q, want 只有中文", got)
+	}
+
+	b.SetDefaultLang("en")
+	if got := b.Tr("fr", "user::login"); got != "Sign in" {
+		t.Errorf("default en = %q, want Sign in", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	b := newTestBundle(t)
+	tests := []struct {
+		langs []string
+		want  string
+	}{
+		{[]string{"en-US", "zh"}, "en"},
+		{[]string{"fr", "zh"}, "zh-CN"},
+		{[]string{"ZH_cn"}, "zh-CN"},
+		{[]string{"fr"}, "zh-CN"},
+		{nil, "zh-CN"},
+	}
+	for _, tt := range tests {
+		if got := b.Match(tt.langs...); got != tt.want {
+			t.Errorf("Match(%v) = %q, want %q", tt.langs, got, tt.want)
+		}
+	}
+
+	if !b.Supports("en-GB") || b.Supports("fr") {
+		t.Error("Supports: en-GB should be supported, fr should not")
+	}
+
+	// 没有语言包
+	if got := New().Match("*", "en-US"); got != "en-us" {
+		t.Errorf("empty Match = %q, want en-us", got)
+	}
+}
+
+func TestPluralRule(t *testing.T) {
+	RegisterPluralRule("cs", func(n int64) string {
+		switch {
+		case n == 1:
+			return One
+		case n >= 2 && n <= 4:
+			return Few
+		}
+		return Other
+	})
+	tests := []struct {
+		lang string
+		n    int64
+		want string
+	}{
+		{"en", 0, Other},
+		{"en-gb", 1, One},
+		{"zh", 1, Other},
+		{"fr", 0, One},
+		{"ru", 21, One},
+		{"ru", 12, Many},
+		{"pl", 5, Many},
+		{"ar", 2, Two},
+		{"cs", 3, Few},
+		{"xx", 1, One},
+	}
+	for _, tt := range tests {
+		if got := pluralRule(tt.lang)(tt.n); got != tt.want {
+			t.Errorf("pluralRule(%q)(%d) = %q, want %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+}
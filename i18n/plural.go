@@ -0,0 +1,139 @@
+package i18n
+
+import (
+	"sync"
+)
+
+// 复数形式，语言包中的 key 为 key_one key_other 等
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// PluralRule 返回 n 的复数形式
+type PluralRule func(n int64) string
+
+var (
+	pluralMu    sync.RWMutex
+	pluralRules = map[string]PluralRule{
+		"zh": pluralOther,
+		"ja": pluralOther,
+		"ko": pluralOther,
+		"vi": pluralOther,
+		"th": pluralOther,
+		"en": pluralOne,
+		"de": pluralOne,
+		"es": pluralOne,
+		"it": pluralOne,
+		"nl": pluralOne,
+		"pt": pluralOne,
+		"fr": pluralFrench,
+		"ru": pluralSlavic,
+		"uk": pluralSlavic,
+		"pl": pluralPolish,
+		"ar": pluralArabic,
+	}
+)
+
+// RegisterPluralRule 注册 lang 的复数规则，lang 为主语言时作用于所有地区
+//		i18n.RegisterPluralRule("cs", func(n int64) string {
+//			switch {
+//			case n == 1:
+//				return i18n.One
+//			case n >= 2 && n <= 4:
+//				return i18n.Few
+//			}
+//			return i18n.Other
+//		})
+func RegisterPluralRule(lang string, rule PluralRule) {
+	pluralMu.Lock()
+	defer pluralMu.Unlock()
+	pluralRules[normalize(lang)] = rule
+}
+
+// pluralRule 返回 lang 或 lang 主语言的复数规则，没有时使用英语的规则
+func pluralRule(lang string) PluralRule {
+	pluralMu.RLock()
+	defer pluralMu.RUnlock()
+	if rule, ok := pluralRules[lang]; ok {
+		return rule
+	}
+	if rule, ok := pluralRules[primary(lang)]; ok {
+		return rule
+	}
+	return pluralOne
+}
+
+// pluralOther 没有复数变化，如中文、日文
+func pluralOther(n int64) string {
+	return Other
+}
+
+// pluralOne 1 为单数，如英语
+func pluralOne(n int64) string {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+// pluralFrench 0 和 1 为单数
+func pluralFrench(n int64) string {
+	if n == 0 || n == 1 {
+		return One
+	}
+	return Other
+}
+
+// pluralSlavic 俄语、乌克兰语
+func pluralSlavic(n int64) string {
+	n = abs(n)
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+// pluralPolish 波兰语
+func pluralPolish(n int64) string {
+	n = abs(n)
+	switch {
+	case n == 1:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+// pluralArabic 阿拉伯语
+func pluralArabic(n int64) string {
+	n = abs(n)
+	switch {
+	case n == 0:
+		return Zero
+	case n == 1:
+		return One
+	case n == 2:
+		return Two
+	case n%100 >= 3 && n%100 <= 10:
+		return Few
+	case n%100 >= 11:
+		return Many
+	}
+	return Other
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
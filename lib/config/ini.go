@@ -37,6 +37,14 @@ func init() {
 		fileName = defaultConfig
 	}
 
+	// 没有设置 APP_RUN_MODE 且没有 conf/app.conf 时使用空配置，各配置项使用默认值
+	//	如 go test 时，工作目录为包所在的目录
+	if fileName == defaultConfig {
+		if _, err := os.Stat(fileName); os.IsNotExist(err) {
+			return
+		}
+	}
+
 	InitLoad(fileName)
 
 }
@@ -292,6 +292,12 @@ func (l *Logger) formatHeader(buf *bytes.Buffer, t time.Time, file string, line
 	}
 }
 
+// Output 输出一条日志，reqId 不为空时写在时间之后
+//	callDepth 为调用 Output 的函数相对于记录位置的层数，1 表示调用 Output 的位置
+func (l *Logger) Output(reqId string, lvl int, callDepth int, s string) error {
+	return l.outPut(reqId, lvl, callDepth+1, s)
+}
+
 //outPut outPut
 func (l *Logger) outPut(reqId string, lvl int, callDepth int, s string) error {
 	if lvl < l.Level {
@@ -40,6 +40,14 @@ func (c *AliClient) Upload(reader io.Reader, dir, ext string) (url string, err e
 	if ext == "" {
 		ext = ".jpg"
 	}
+	uuid, _ := util.GetUUID()
+	filePath := fmt.Sprintf("%s/%s/%s", dir, time.Now().Format("20060102"), uuid+ext)
+	return c.PutObject(filePath, reader)
+}
+
+// PutObject 使用指定的 objectKey 上传，不修改文件名和扩展名
+//		url,err:=client.PutObject("upload/20200101/a.png",reader)
+func (c *AliClient) PutObject(objectKey string, reader io.Reader) (url string, err error) {
 	client, err := oss.New(c.EndPoint, c.AccessKeyId, c.Secret)
 	if err != nil {
 		err = fmt.Errorf("[client]init失败:%v", err)
@@ -49,16 +57,28 @@ func (c *AliClient) Upload(reader io.Reader, dir, ext string) (url string, err e
 	if err != nil {
 		return
 	}
-	uuid, _ := util.GetUUID()
-	filePath := fmt.Sprintf("%s/%s/%s", dir, time.Now().Format("20060102"), uuid+ext)
-	err = bucket.PutObject(filePath, reader)
+	err = bucket.PutObject(objectKey, reader)
 	if err != nil {
 		return
 	}
-	url = fmt.Sprintf("%s%s", c.ServerUrl, filePath)
+	url = fmt.Sprintf("%s%s", c.ServerUrl, objectKey)
 	return
 }
 
+// DeleteObject 删除 objectKey，objectKey 不存在时不返回错误
+//		err:=client.DeleteObject("upload/20200101/a.png")
+func (c *AliClient) DeleteObject(objectKey string) error {
+	client, err := oss.New(c.EndPoint, c.AccessKeyId, c.Secret)
+	if err != nil {
+		return fmt.Errorf("[client]init失败:%v", err)
+	}
+	bucket, err := client.Bucket(c.BucketName)
+	if err != nil {
+		return err
+	}
+	return bucket.DeleteObject(objectKey)
+}
+
 // UploadRemoteFile 上传网络图片到oss
 func (c *AliClient) UploadRemoteFile(httpUrl, dir string) (url string, err error) {
 	resp, err := http.Get(httpUrl)
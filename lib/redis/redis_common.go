@@ -182,6 +182,58 @@ func (m *RDSCommon) HashFieldExists(key, field string) (bool, error) {
 	return redis.Bool(rc.Do("HEXISTS", redis.Args{}.Add(key).Add(field)...))
 }
 
+//GetHashStringMap 获取hash的所有field和值，key不存在时返回空map
+func (m *RDSCommon) GetHashStringMap(key string) (map[string]string, error) {
+	rc := m.client.Get()
+	defer rc.Close()
+	return redis.StringMap(rc.Do("HGETALL", key))
+}
+
+//SetHashEx 在一个事务中替换 hash 的所有 field，并设置过期时间
+//	ex 为0时不设置过期时间
+func (m *RDSCommon) SetHashEx(key string, fields map[string]interface{}, ex int64) error {
+	rc := m.client.Get()
+	defer rc.Close()
+	rc.Send("MULTI")
+	rc.Send("DEL", key)
+	if len(fields) > 0 {
+		rc.Send("HMSET", redis.Args{}.Add(key).AddFlat(fields)...)
+	}
+	if ex > 0 {
+		rc.Send("EXPIRE", key, ex)
+	}
+	_, err := rc.Do("EXEC")
+	return err
+}
+
+// updateHashScript key 存在时才修改
+//	ARGV: 过期时间 删除的field数量 删除的field... field 值 field 值...
+var updateHashScript = redis.NewScript(1, `
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+local n = tonumber(ARGV[2])
+for i = 3, n + 2 do
+	redis.call("HDEL", KEYS[1], ARGV[i])
+end
+for i = n + 3, #ARGV, 2 do
+	redis.call("HSET", KEYS[1], ARGV[i], ARGV[i + 1])
+end
+if tonumber(ARGV[1]) > 0 then
+	redis.call("EXPIRE", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+//UpdateHashEx key 存在时，原子地删除 del 中的 field，设置 fields 中的值，并设置过期时间
+//	key 不存在(已删除或过期)时不修改，返回 false；ex 为0时不设置过期时间
+func (m *RDSCommon) UpdateHashEx(key string, fields map[string]interface{}, del []string, ex int64) (bool, error) {
+	rc := m.client.Get()
+	defer rc.Close()
+	args := redis.Args{}.Add(key, ex, len(del)).AddFlat(del).AddFlat(fields)
+	return redis.Bool(updateHashScript.Do(rc, args...))
+}
+
 //================list======================
 
 //================zset======================
@@ -45,6 +45,27 @@ var (
 
 //InitRDSClient init config
 func InitRDSClient(rdc *RDSConfig) (err error) {
+	pool, err := newPool(rdc)
+	if err != nil {
+		return
+	}
+	redisClient = pool
+	return
+}
+
+// NewRDSCommon 使用独立的连接池，不影响 InitRDSClient 初始化的共用连接
+//	用于 session 等需要连接不同 redis 或 DB 的场景
+//		rc, err := redis.NewRDSCommon(&redis.RDSConfig{Host: "127.0.0.1", Port: 6379, DB: 1})
+func NewRDSCommon(rdc *RDSConfig) (*RDSCommon, error) {
+	pool, err := newPool(rdc)
+	if err != nil {
+		return nil, err
+	}
+	return &RDSCommon{client: pool}, nil
+}
+
+// newPool 根据配置创建连接池
+func newPool(rdc *RDSConfig) (pool *redis.Pool, err error) {
 	if rdc == nil {
 		err = fmt.Errorf("没有需要init的redis")
 		return
@@ -53,7 +74,7 @@ func InitRDSClient(rdc *RDSConfig) (err error) {
 		err = fmt.Errorf("[RDS]没有配置主机或端口")
 		return
 	}
-	redisClient = &redis.Pool{
+	pool = &redis.Pool{
 		MaxIdle:     rdc.MaxIdle,
 		MaxActive:   rdc.MaxActive,
 		IdleTimeout: 5 * time.Second,
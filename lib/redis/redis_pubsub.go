@@ -0,0 +1,58 @@
+package redis
+
+import (
+	"context"
+	"github.com/gomodule/redigo/redis"
+)
+
+//==============发布订阅========================
+// Publish 发布消息，返回收到消息的订阅者数量
+func (m *RDSCommon) Publish(channel string, v interface{}) (int64, error) {
+	rc := m.client.Get()
+	defer rc.Close()
+	return redis.Int64(rc.Do("PUBLISH", channel, v))
+}
+
+// Subscribe 订阅 channels，收到消息时调用 fn
+//	阻塞直到 ctx 取消或连接出错，ctx 取消时返回 nil
+func (m *RDSCommon) Subscribe(ctx context.Context, fn func(channel string, data []byte), channels ...string) error {
+	return m.SubscribeNotify(ctx, fn, nil, channels...)
+}
+
+// SubscribeNotify 同 Subscribe，redis 确认订阅所有 channels 后调用 ready
+//	可用于区分连接失败和订阅成功后连接断开
+func (m *RDSCommon) SubscribeNotify(ctx context.Context, fn func(channel string, data []byte), ready func(), channels ...string) error {
+	rc := m.client.Get()
+	psc := redis.PubSubConn{Conn: rc}
+	defer psc.Close()
+	if err := psc.Subscribe(redis.Args{}.AddFlat(channels)...); err != nil {
+		return err
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			switch msg := psc.Receive().(type) {
+			case redis.Message:
+				fn(msg.Channel, msg.Data)
+			case redis.Subscription:
+				if ready != nil && msg.Kind == "subscribe" && msg.Count == len(channels) {
+					ready()
+				}
+			case error:
+				errc <- msg
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		psc.Unsubscribe()
+		rc.Close()
+		<-errc
+		return nil
+	case err := <-errc:
+		return err
+	}
+}
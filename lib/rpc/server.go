@@ -4,12 +4,20 @@
 server,err:=NewServer(10000)
 server.Run()
 
+与 gow 共用端口：
+
+r := gow.Default()
+server := NewEngineServer(r)
+pb.RegisterUserServer(server.Server, &UserServer{})
+r.Run()
+
 */
 
 package rpc
 
 import (
 	"fmt"
+	"github.com/gkzy/gow"
 	"github.com/gkzy/gow/lib/logy"
 	"google.golang.org/grpc"
 	"net"
@@ -41,6 +49,16 @@ func NewServer(port int) (server *Server, err error) {
 	return
 }
 
+//NewEngineServer 返回挂载到 engine 上的服务，与 HTTP 路由共用端口
+//	engine 关闭时，服务一起关闭，不需要调用 Run
+func NewEngineServer(engine *gow.Engine, opt ...grpc.ServerOption) *Server {
+	server := &Server{
+		Server: grpc.NewServer(opt...),
+	}
+	engine.MountGRPC(server.Server)
+	return server
+}
+
 //Run run rpc server
 func (m *Server) Run() {
 	go func() {
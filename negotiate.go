@@ -0,0 +1,171 @@
+package gow
+
+import (
+	"fmt"
+	"github.com/gkzy/gow/render"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MIME
+const (
+	MIMEJSON     = "application/json"
+	MIMEHTML     = "text/html"
+	MIMEXML      = "application/xml"
+	MIMEXML2     = "text/xml"
+	MIMEPlain    = "text/plain"
+	MIMEYAML     = "application/x-yaml"
+	MIMEProtoBuf = "application/x-protobuf"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEMsgPack2 = "application/x-msgpack"
+)
+
+// RenderFunc 根据 data 返回 render.Render
+type RenderFunc func(data interface{}) render.Render
+
+var (
+	renderFuncsMu sync.RWMutex
+	renderFuncs   = map[string]RenderFunc{
+		MIMEJSON:     func(data interface{}) render.Render { return render.JSON{Data: data} },
+		MIMEXML:      func(data interface{}) render.Render { return render.XML{Data: data} },
+		MIMEXML2:     func(data interface{}) render.Render { return render.XML{Data: data} },
+		MIMEYAML:     func(data interface{}) render.Render { return render.YAML{Data: data} },
+		MIMEProtoBuf: func(data interface{}) render.Render { return render.ProtoBuf{Data: data} },
+		MIMEMsgPack:  func(data interface{}) render.Render { return render.MsgPack{Data: data} },
+		MIMEMsgPack2: func(data interface{}) render.Render { return render.MsgPack{Data: data} },
+		MIMEPlain:    func(data interface{}) render.Render { return render.String{Data: fmt.Sprint(data)} },
+	}
+)
+
+// RegisterRender 注册 Negotiate 使用的格式，可以覆盖内置的格式
+//		gow.RegisterRender("text/csv", func(data interface{}) render.Render {
+//			return CSV{Data: data}
+//		})
+func RegisterRender(mime string, fn RenderFunc) {
+	renderFuncsMu.Lock()
+	renderFuncs[mime] = fn
+	renderFuncsMu.Unlock()
+}
+
+// Negotiate Negotiate 的参数
+type Negotiate struct {
+	// Offered 可以返回的 MIME，按优先级排列，为空时为 JSON XML YAML
+	Offered []string
+	// HTMLName 返回 text/html 时渲染的模板
+	HTMLName string
+	// Data 数据，HTML 时合并到 c.Data 中(Data 为 gow.H 时)
+	Data interface{}
+}
+
+// Negotiate 根据 Accept 选择响应格式
+//	没有可以接受的格式时，返回406
+//		c.Negotiate(http.StatusOK, gow.Negotiate{
+//			Offered:  []string{gow.MIMEJSON, gow.MIMEHTML, gow.MIMEXML},
+//			HTMLName: "user.html",
+//			Data:     user,
+//		})
+func (c *Context) Negotiate(statusCode int, config Negotiate) {
+	offered := config.Offered
+	if len(offered) == 0 {
+		offered = []string{MIMEJSON, MIMEXML, MIMEYAML}
+	}
+	format := c.NegotiateFormat(offered...)
+	if format == "" {
+		c.AbortWithError(ErrNotAcceptable)
+		return
+	}
+
+	if format == MIMEHTML {
+		if h, ok := config.Data.(H); ok {
+			for k, v := range h {
+				c.Data[k] = v
+			}
+		} else if config.Data != nil {
+			c.Data["data"] = config.Data
+		}
+		c.ServerHTML(statusCode, config.HTMLName)
+		return
+	}
+	renderFuncsMu.RLock()
+	fn, ok := renderFuncs[format]
+	renderFuncsMu.RUnlock()
+	if !ok {
+		c.AbortWithError(ErrNotAcceptable)
+		return
+	}
+	c.Render(statusCode, fn(config.Data))
+}
+
+// NegotiateFormat 返回 offered 中与 Accept 最匹配的 MIME
+//	没有 Accept 时返回第一个，都不能接受时返回空字符串
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accepts := parseAccept(c.GetHeader("Accept"))
+	if len(accepts) == 0 {
+		return offered[0]
+	}
+	for _, accept := range accepts {
+		for _, mime := range offered {
+			if matchMIME(accept, mime) {
+				return mime
+			}
+		}
+	}
+	return ""
+}
+
+// acceptItem Accept 中的一项
+type acceptItem struct {
+	mime string
+	q    float64
+}
+
+// parseAccept 解析 Accept，按 q 值从大到小排列，去掉 q=0 的项
+func parseAccept(header string) []string {
+	if header == "" {
+		return nil
+	}
+	var items []acceptItem
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mime := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mime == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			items = append(items, acceptItem{mime: mime, q: q})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].q > items[j].q
+	})
+	ret := make([]string, len(items))
+	for i, item := range items {
+		ret[i] = item.mime
+	}
+	return ret
+}
+
+// matchMIME accept 可以是 */* 或 type/*
+func matchMIME(accept, mime string) bool {
+	if accept == "*/*" || accept == "*" || accept == mime {
+		return true
+	}
+	if strings.HasSuffix(accept, "/*") {
+		return strings.HasPrefix(mime, accept[:len(accept)-1])
+	}
+	return false
+}
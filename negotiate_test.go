@@ -0,0 +1,156 @@
+package gow
+
+import (
+	"github.com/gkzy/gow/render"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/vmihailenco/msgpack/v4"
+	"gopkg.in/yaml.v2"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	offered := []string{MIMEJSON, MIMEXML, MIMEYAML}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", MIMEJSON},
+		{"application/xml", MIMEXML},
+		{"text/xml;q=0.5, application/x-yaml", MIMEYAML},
+		{"application/xml;q=0.8, application/json;q=0.9", MIMEJSON},
+		{"application/*", MIMEJSON},
+		{"*/*", MIMEJSON},
+		{"APPLICATION/XML", MIMEXML},
+		// q=0 表示不接受
+		{"application/json;q=0, application/xml;q=0.1", MIMEXML},
+		{"text/html", ""},
+	}
+	for _, tt := range tests {
+		r := New()
+		var got string
+		r.GET("/", func(c *Context) {
+			got = c.NegotiateFormat(offered...)
+		})
+		performRequest(r, "GET", "/", nil, map[string]string{"Accept": tt.accept})
+		if got != tt.want {
+			t.Errorf("Accept %q: got %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{
+			Offered: []string{MIMEJSON, MIMEXML, MIMEYAML, MIMEMsgPack, MIMEPlain},
+			Data:    H{"name": "gow"},
+		})
+	})
+	tests := []struct {
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"application/json", http.StatusOK, "application/json", `{"name":"gow"}`},
+		{"application/x-yaml", http.StatusOK, "application/x-yaml", "name: gow\n"},
+		{"text/plain", http.StatusOK, "text/plain", "map[name:gow]"},
+		{"image/png", http.StatusNotAcceptable, "application/json", `"code": 406`},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, "GET", "/", nil, map[string]string{"Accept": tt.accept})
+		if w.Code != tt.code || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("Accept %s: got %d %s %q", tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+
+	// 注册的格式
+	RegisterRender("text/csv", func(data interface{}) render.Render {
+		return render.String{Data: "name\ngow\n"}
+	})
+	r.GET("/csv", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{Offered: []string{"text/csv"}, Data: H{"name": "gow"}})
+	})
+	if w := performRequest(r, "GET", "/csv", nil, map[string]string{"Accept": "text/csv"}); w.Body.String() != "name\ngow\n" {
+		t.Errorf("registered render: got %q", w.Body.String())
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	r := New()
+	r.GET("/jsonp", func(c *Context) {
+		c.JSONP(H{"a": 1})
+	})
+	r.GET("/secure", func(c *Context) {
+		c.SecureJSON([]int{1, 2})
+	})
+	r.GET("/secure-object", func(c *Context) {
+		c.SecureJSON(H{"a": 1})
+	})
+	r.GET("/pure", func(c *Context) {
+		c.PureJSON(H{"html": "<b>&</b>"})
+	})
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+	}{
+		{"/jsonp?callback=show", "application/javascript", `/**/ typeof show === 'function' && show({"a":1});`},
+		{"/jsonp?callback=app.cb_1", "application/javascript", `/**/ typeof app.cb_1 === 'function' && app.cb_1({"a":1});`},
+		// 不合法的 callback 返回 json
+		{"/jsonp?callback=alert(1)//", "application/json", `{"a":1}` + "\n"},
+		{"/jsonp?callback=%3Cscript%3E", "application/json", `{"a":1}` + "\n"},
+		{"/jsonp", "application/json", `{"a":1}` + "\n"},
+		{"/secure", "application/json", "while(1);[1,2]"},
+		{"/secure-object", "application/json", `{"a":1}`},
+		{"/pure", "application/json", `{"html":"<b>&</b>"}` + "\n"},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, "GET", tt.path, nil, nil)
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) || w.Body.String() != tt.body {
+			t.Errorf("%s: got %s %q, want %s %q", tt.path, w.Header().Get("Content-Type"), w.Body.String(), tt.contentType, tt.body)
+		}
+	}
+
+	r.SecureJSONPrefix = ")]}',\n"
+	if w := performRequest(r, "GET", "/secure", nil, nil); w.Body.String() != ")]}',\n[1,2]" {
+		t.Errorf("custom prefix: got %q", w.Body.String())
+	}
+}
+
+func TestRenderBinary(t *testing.T) {
+	type user struct {
+		Name string `yaml:"name" msgpack:"name"`
+	}
+	r := New()
+	r.GET("/yaml", func(c *Context) {
+		c.YAML(user{Name: "gow"})
+	})
+	r.GET("/msgpack", func(c *Context) {
+		c.MsgPack(user{Name: "gow"})
+	})
+	r.GET("/protobuf", func(c *Context) {
+		c.ProtoBuf(&wrappers.StringValue{Value: "gow"})
+	})
+
+	w := performRequest(r, "GET", "/yaml", nil, nil)
+	var u user
+	if err := yaml.Unmarshal(w.Body.Bytes(), &u); err != nil || u.Name != "gow" || !strings.HasPrefix(w.Header().Get("Content-Type"), MIMEYAML) {
+		t.Errorf("yaml: got %s %q, %v", w.Header().Get("Content-Type"), w.Body.String(), err)
+	}
+
+	w = performRequest(r, "GET", "/msgpack", nil, nil)
+	u = user{}
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &u); err != nil || u.Name != "gow" || w.Header().Get("Content-Type") != MIMEMsgPack {
+		t.Errorf("msgpack: got %s %q, %v", w.Header().Get("Content-Type"), w.Body.String(), err)
+	}
+
+	w = performRequest(r, "GET", "/protobuf", nil, nil)
+	var msg wrappers.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil || msg.Value != "gow" || w.Header().Get("Content-Type") != MIMEProtoBuf {
+		t.Errorf("protobuf: got %s %q, %v", w.Header().Get("Content-Type"), w.Body.String(), err)
+	}
+}
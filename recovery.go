@@ -3,7 +3,6 @@ package gow
 import (
 	"fmt"
 	"log"
-	"net/http"
 	"runtime"
 	"strings"
 )
@@ -29,7 +28,7 @@ func Recovery() HandlerFunc {
 			if err := recover(); err != nil {
 				message := fmt.Sprintf("%s", err)
 				log.Printf("%s\n\n", trace(message))
-				c.Fail(http.StatusInternalServerError, "Internal Server Error")
+				c.AbortWithError(ErrInternal.Wrap(fmt.Errorf("panic: %s", message)))
 			}
 		}()
 
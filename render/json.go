@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+)
+
+var (
+	jsonContentType       = []string{"application/json; charset=utf-8"}
+	javascriptContentType = []string{"application/javascript; charset=utf-8"}
+
+	// callbackRegexp JSONP 的 callback 只允许 js 标识符，防止 XSS
+	callbackRegexp = regexp.MustCompile(`^[a-zA-Z_$][\w$]*(\.[a-zA-Z_$][\w$]*)*$`)
+)
+
+// JSON json
+type JSON struct {
+	Data   interface{}
+	Indent bool
+}
+
+// PureJSON 不转义 < > & 等 html 字符的 json
+type PureJSON struct {
+	Data interface{}
+}
+
+// SecureJSON 在 json 数组前加上前缀，防止 json 劫持
+//	Prefix 为空时使用 while(1);
+type SecureJSON struct {
+	Prefix string
+	Data   interface{}
+}
+
+// JSONP jsonp
+//	Callback 为空或不合法时，返回 json
+type JSONP struct {
+	Callback string
+	Data     interface{}
+}
+
+// Render Render
+func (r JSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	encoder := json.NewEncoder(w)
+	if r.Indent {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(r.Data)
+}
+
+// WriteContentType WriteContentType
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// Render Render
+func (r PureJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(r.Data)
+}
+
+// WriteContentType WriteContentType
+func (r PureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// Render Render
+func (r SecureJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	b, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	// 只有数组才会被劫持
+	if bytes.HasPrefix(b, []byte("[")) && bytes.HasSuffix(b, []byte("]")) {
+		prefix := r.Prefix
+		if prefix == "" {
+			prefix = "while(1);"
+		}
+		if _, err = w.Write([]byte(prefix)); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteContentType WriteContentType
+func (r SecureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+// Render Render
+func (r JSONP) Render(w http.ResponseWriter) error {
+	if !callbackRegexp.MatchString(r.Callback) {
+		return JSON{Data: r.Data}.Render(w)
+	}
+	r.WriteContentType(w)
+	b, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	// /**/ 防止 Rosetta Flash 攻击
+	_, err = w.Write([]byte("/**/ typeof " + r.Callback + " === 'function' && " + r.Callback + "("))
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(b); err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(");"))
+	return err
+}
+
+// WriteContentType WriteContentType
+func (r JSONP) WriteContentType(w http.ResponseWriter) {
+	if !callbackRegexp.MatchString(r.Callback) {
+		writeContentType(w, jsonContentType)
+		return
+	}
+	writeContentType(w, javascriptContentType)
+}
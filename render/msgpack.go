@@ -0,0 +1,24 @@
+package render
+
+import (
+	"github.com/vmihailenco/msgpack/v4"
+	"net/http"
+)
+
+var msgpackContentType = []string{"application/msgpack"}
+
+// MsgPack MessagePack
+type MsgPack struct {
+	Data interface{}
+}
+
+// Render Render
+func (r MsgPack) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return msgpack.NewEncoder(w).Encode(r.Data)
+}
+
+// WriteContentType WriteContentType
+func (r MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType)
+}
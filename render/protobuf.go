@@ -0,0 +1,38 @@
+package render
+
+import (
+	"errors"
+	"github.com/golang/protobuf/proto"
+	"net/http"
+)
+
+var protobufContentType = []string{"application/x-protobuf"}
+
+// ErrNotProtoMessage ProtoBuf 的 Data 不是 proto.Message
+var ErrNotProtoMessage = errors.New("render: data is not a proto.Message")
+
+// ProtoBuf protocol buffers
+//	Data 必须是 proto.Message
+type ProtoBuf struct {
+	Data interface{}
+}
+
+// Render Render
+func (r ProtoBuf) Render(w http.ResponseWriter) error {
+	msg, ok := r.Data.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	r.WriteContentType(w)
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteContentType WriteContentType
+func (r ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, protobufContentType)
+}
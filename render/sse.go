@@ -0,0 +1,65 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var sseContentType = []string{"text/event-stream"}
+
+// newlineReplacer id 和 event 中不能有换行
+var newlineReplacer = strings.NewReplacer("\n", "", "\r", "")
+
+// SSEvent Server-Sent Event
+//	Data 为 string 或 []byte 时原样输出，多行时拆分为多个 data 行，其他类型使用 json
+type SSEvent struct {
+	ID    string
+	Event string
+	Retry uint //客户端重连的等待时间，单位为毫秒
+	Data  interface{}
+}
+
+// Render Render
+func (r SSEvent) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	var buf bytes.Buffer
+	if r.ID != "" {
+		buf.WriteString("id:" + newlineReplacer.Replace(r.ID) + "\n")
+	}
+	if r.Event != "" {
+		buf.WriteString("event:" + newlineReplacer.Replace(r.Event) + "\n")
+	}
+	if r.Retry > 0 {
+		buf.WriteString("retry:" + strconv.FormatUint(uint64(r.Retry), 10) + "\n")
+	}
+
+	var data []byte
+	switch v := r.Data.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data:")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteContentType WriteContentType
+func (r SSEvent) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, sseContentType)
+}
@@ -0,0 +1,44 @@
+package render
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+var (
+	plainContentType = []string{"text/plain; charset=utf-8"}
+	xmlContentType   = []string{"application/xml; charset=utf-8"}
+)
+
+// String plain text
+type String struct {
+	Data string
+}
+
+// XML xml
+type XML struct {
+	Data interface{}
+}
+
+// Render Render
+func (r String) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	_, err := w.Write([]byte(r.Data))
+	return err
+}
+
+// WriteContentType WriteContentType
+func (r String) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, plainContentType)
+}
+
+// Render Render
+func (r XML) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return xml.NewEncoder(w).Encode(r.Data)
+}
+
+// WriteContentType WriteContentType
+func (r XML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, xmlContentType)
+}
@@ -0,0 +1,29 @@
+package render
+
+import (
+	"gopkg.in/yaml.v2"
+	"net/http"
+)
+
+var yamlContentType = []string{"application/x-yaml; charset=utf-8"}
+
+// YAML yaml
+type YAML struct {
+	Data interface{}
+}
+
+// Render Render
+func (r YAML) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	b, err := yaml.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteContentType WriteContentType
+func (r YAML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, yamlContentType)
+}
@@ -0,0 +1,186 @@
+package gow
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	// envListeners 热重启时传给子进程的 listener 列表
+	//	格式: network:addr,network:addr，依次对应 fd 3,4,...
+	envListeners = "GOW_LISTENERS"
+)
+
+var (
+	inheritedOnce sync.Once
+	inheritedMu   sync.Mutex
+	inherited     map[string]net.Listener
+)
+
+// engineListener 由 engine.listen 创建的 listener
+type engineListener struct {
+	network string
+	addr    string
+	net.Listener
+}
+
+// filer 可以返回文件描述符的 listener
+//	*net.TCPListener *net.UnixListener
+type filer interface {
+	File() (*os.File, error)
+}
+
+// SetHotRestartOn 开启热重启
+//	收到 SIGHUP 后，启动新的进程并把正在监听的 socket 交给它，
+//	然后平滑关闭当前进程，重启期间不会拒绝新的连接
+//	只对 Run RunTLS RunUnix RunHTTPAndTLS 创建的 listener 生效
+//		r := gow.Default()
+//		r.SetHotRestartOn(true)
+//		r.Run()
+//	重启：
+//		kill -HUP <pid>
+func (engine *Engine) SetHotRestartOn(on bool) {
+	engine.HotRestartOn = on
+}
+
+//================================private func=============================
+
+// listen 在 addr 上监听
+//	热重启的子进程优先使用父进程传入的 listener
+func (engine *Engine) listen(network, addr string) (net.Listener, error) {
+	ln := inheritedListener(network, addr)
+	if ln == nil {
+		if network == "unix" {
+			// 删除遗留的 socket 文件
+			if fi, err := os.Stat(addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+				os.Remove(addr)
+			}
+		}
+		var err error
+		ln, err = net.Listen(network, addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		debugPrint("[%s] Use the inherited listener %s:%s", engine.AppName, network, addr)
+	}
+
+	el := &engineListener{network: network, addr: addr, Listener: ln}
+	engine.mu.Lock()
+	engine.listeners = append(engine.listeners, el)
+	engine.mu.Unlock()
+	return el, nil
+}
+
+// restart 启动新的进程，并把 listener 的文件描述符传给它
+func (engine *Engine) restart() (err error) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	if engine.shutting || engine.restarting {
+		return fmt.Errorf("[%s] server is shutting down", engine.AppName)
+	}
+	if len(engine.listeners) == 0 {
+		return fmt.Errorf("[%s] no listener to hand over", engine.AppName)
+	}
+
+	var (
+		files   = make([]*os.File, 0, len(engine.listeners))
+		entries = make([]string, 0, len(engine.listeners))
+	)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, el := range engine.listeners {
+		fl, ok := el.Listener.(filer)
+		if !ok {
+			return fmt.Errorf("[%s] listener %s:%s can not be handed over", engine.AppName, el.network, el.addr)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		entries = append(entries, el.network+":"+el.addr)
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return
+	}
+	env := make([]string, 0)
+	for _, item := range os.Environ() {
+		if !strings.HasPrefix(item, envListeners+"=") {
+			env = append(env, item)
+		}
+	}
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Env = append(env, envListeners+"="+strings.Join(entries, ","))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	if err = cmd.Start(); err != nil {
+		return
+	}
+
+	// socket 文件由子进程继续使用，关闭时不删除
+	for _, el := range engine.listeners {
+		if ul, ok := el.Listener.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	engine.restarting = true
+	debugPrint("[%s] Restarted, new process pid: %d", engine.AppName, cmd.Process.Pid)
+	return
+}
+
+// isRestarting 是否已经交给新进程
+func (engine *Engine) isRestarting() bool {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	return engine.restarting
+}
+
+// inheritedListener 返回父进程传入的 listener，并从列表中移除
+//	没有时返回nil
+func inheritedListener(network, addr string) net.Listener {
+	inheritedOnce.Do(loadInheritedListeners)
+
+	inheritedMu.Lock()
+	defer inheritedMu.Unlock()
+	key := network + ":" + addr
+	ln, ok := inherited[key]
+	if !ok {
+		return nil
+	}
+	delete(inherited, key)
+	return ln
+}
+
+// loadInheritedListeners 读取父进程通过环境变量传入的 listener
+func loadInheritedListeners() {
+	inherited = make(map[string]net.Listener)
+	val := os.Getenv(envListeners)
+	if val == "" {
+		return
+	}
+	os.Unsetenv(envListeners)
+	for i, key := range strings.Split(val, ",") {
+		fd := 3 + i
+		f := os.NewFile(uintptr(fd), key)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			debugPrintError(fmt.Errorf("inherited listener %s (fd %d): %v", key, fd, err))
+			continue
+		}
+		inherited[key] = ln
+	}
+}
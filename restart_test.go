@@ -0,0 +1,87 @@
+package gow
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperInheritedListener 作为热重启的子进程运行，使用父进程传入的 listener
+func TestHelperInheritedListener(t *testing.T) {
+	addr := os.Getenv("GOW_TEST_CHILD_ADDR")
+	if addr == "" {
+		return
+	}
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.String("child")
+	})
+	r.Run(addr)
+}
+
+// TestHotRestart_Handoff 子进程通过 GOW_LISTENERS 和 fd 3 接管 listener，地址不变
+func TestHotRestart_Handoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不关闭，子进程不使用传入的 listener 时会因为端口被占用而启动失败
+	defer ln.Close()
+	addr := ln.Addr().String()
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperInheritedListener")
+	cmd.Env = append(os.Environ(), "GOW_TEST_CHILD_ADDR="+addr, envListeners+"=tcp:"+addr)
+	cmd.ExtraFiles = []*os.File{f}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	f.Close()
+
+	client := &http.Client{Timeout: 2 * time.Second}
+	var resp *http.Response
+	for i := 0; i < 200; i++ {
+		if resp, err = client.Get("http://" + addr + "/"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "child" {
+		t.Errorf("response = %q, want child", b)
+	}
+}
+
+func TestRestart_NoListener(t *testing.T) {
+	r := New()
+	if err := r.restart(); err == nil || !strings.Contains(err.Error(), "no listener") {
+		t.Errorf("restart() = %v, want no listener error", err)
+	}
+
+	// 不能交给子进程的 listener
+	r.listeners = append(r.listeners, &engineListener{network: "test", addr: "test", Listener: fakeListener{}})
+	if err := r.restart(); err == nil || !strings.Contains(err.Error(), "can not be handed over") {
+		t.Errorf("restart() = %v, want can not be handed over", err)
+	}
+}
+
+// fakeListener 没有文件描述符的 listener
+type fakeListener struct {
+	net.Listener
+}
@@ -0,0 +1,361 @@
+package gow
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/gkzy/gow/render"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultShutdownTimeout = 10 * time.Second
+
+	// RunMode 为 prod 时，http.Server 的默认值
+	//	不设置 ReadTimeout WriteTimeout，否则会中断 SSE、大文件下载和上传
+	prodReadHeaderTimeout = 10 * time.Second
+	prodIdleTimeout       = 120 * time.Second
+	prodMaxHeaderBytes    = 1 << 20
+)
+
+// SetGracefulOn 开启平滑关闭
+//	收到 SIGINT/SIGTERM 后停止接收新连接，并在 timeout 内等待正在处理的请求完成
+//	timeout 不设置时，默认为10秒
+//		r := gow.Default()
+//		r.SetGracefulOn(true, 30*time.Second)
+func (engine *Engine) SetGracefulOn(on bool, timeout ...time.Duration) {
+	engine.GracefulOn = on
+	if len(timeout) > 0 {
+		engine.ShutdownTimeout = timeout[0]
+	}
+}
+
+// GRPCServer 可以挂载到 Engine 上的 grpc 服务
+//	*grpc.Server 实现了此接口
+type GRPCServer interface {
+	http.Handler
+	GracefulStop()
+}
+
+// MountGRPC 挂载 grpc 服务，与 HTTP 路由共用端口
+//	content-type 为 application/grpc 的 HTTP/2 请求交给 grpc 服务处理，其他请求交给路由处理
+//	不使用 TLS 时，需要 h2c 支持，此方法会开启 H2COn
+//	Shutdown 时，在 HTTP 服务关闭后调用 GracefulStop
+//		s := grpc.NewServer()
+//		pb.RegisterUserServer(s, &UserServer{})
+//		r.MountGRPC(s)
+func (engine *Engine) MountGRPC(server GRPCServer) {
+	engine.grpcServer = server
+	engine.H2COn = true
+}
+
+// OnStart 注册服务启动前执行的函数
+//	按注册顺序执行，任意一个返回error时，服务不会启动
+func (engine *Engine) OnStart(fn ...func() error) {
+	engine.startHooks = append(engine.startHooks, fn...)
+}
+
+// OnShutdown 注册服务关闭时执行的函数
+//	在所有请求处理完成后，按注册顺序执行
+//		r.OnShutdown(func() error {
+//			return mysql.GetORM().Close()
+//		})
+func (engine *Engine) OnShutdown(fn ...func() error) {
+	engine.shutdownHooks = append(engine.shutdownHooks, fn...)
+}
+
+// Shutdown 平滑关闭服务
+//	停止接收新连接，等待正在处理的请求(包括 h2c 连接上的请求)完成后执行 OnShutdown 注册的函数
+//	ctx 超时后，强制关闭未完成的连接
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.shutdownOnce.Do(func() {
+		engine.mu.Lock()
+		engine.shutting = true
+		servers := engine.servers
+		engine.mu.Unlock()
+
+		debugPrint("[%s] Shutting down server...", engine.AppName)
+		var err error
+		for _, srv := range servers {
+			if e := srv.Shutdown(ctx); e != nil {
+				srv.Close()
+				if err == nil {
+					err = e
+				}
+			}
+		}
+		// h2c 连接已被 Hijack，srv.Shutdown 只会通知其关闭，不会等待
+		if e := engine.h2cConns.wait(ctx); e != nil && err == nil {
+			err = e
+		}
+		if engine.grpcServer != nil {
+			engine.grpcServer.GracefulStop()
+		}
+		for _, fn := range engine.shutdownHooks {
+			if e := fn(); e != nil {
+				debugPrintError(e)
+				if err == nil {
+					err = e
+				}
+			}
+		}
+		engine.shutdownErr = err
+		close(engine.done)
+		debugPrint("[%s] Server exited", engine.AppName)
+	})
+	<-engine.done
+	return engine.shutdownErr
+}
+
+//================================private func=============================
+
+// prepare 构建模板，输出启动信息并执行 OnStart 注册的函数
+//	多次调用时只执行一次
+func (engine *Engine) prepare() error {
+	engine.startOnce.Do(func() {
+		if engine.AutoRender {
+			//builder template
+			if err := render.AddViewPath(engine.viewsPath); err != nil {
+				debugPrintError(err)
+			}
+		}
+
+		if engine.RunMode == devMode {
+			fmt.Println(logo)
+			debugPrint("package: %s", pkg)
+			debugPrint("website: %s", site)
+		}
+
+		for _, fn := range engine.startHooks {
+			if err := fn(); err != nil {
+				engine.startErr = err
+				return
+			}
+		}
+
+		if engine.GracefulOn || engine.HotRestartOn {
+			go engine.handleSignal()
+		}
+	})
+	return engine.startErr
+}
+
+// newServer 返回一个使用 engine 处理请求的 http.Server
+//	RunMode 为 prod 时，未设置的 ReadHeaderTimeout IdleTimeout MaxHeaderBytes 使用默认值
+//	tlsConfig 为nil时，提供 HTTP 服务，开启 H2COn 时同时支持 h2c；使用 TLS 时通过 ALPN 支持 HTTP/2
+func (engine *Engine) newServer(addr string, tlsConfig *tls.Config) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           engine.handler(),
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       engine.ReadTimeout,
+		ReadHeaderTimeout: engine.ReadHeaderTimeout,
+		WriteTimeout:      engine.WriteTimeout,
+		IdleTimeout:       engine.IdleTimeout,
+		MaxHeaderBytes:    engine.MaxHeaderBytes,
+	}
+	if engine.RunMode == prodMode {
+		if srv.ReadHeaderTimeout == 0 {
+			srv.ReadHeaderTimeout = prodReadHeaderTimeout
+		}
+		if srv.IdleTimeout == 0 {
+			srv.IdleTimeout = prodIdleTimeout
+		}
+		if srv.MaxHeaderBytes == 0 {
+			srv.MaxHeaderBytes = prodMaxHeaderBytes
+		}
+	}
+
+	if engine.H2COn && tlsConfig == nil {
+		// 同时支持 prior knowledge 和 Upgrade: h2c 两种方式
+		//	ConfigureServer 后，srv.Shutdown 会通知 h2c 连接关闭(GOAWAY)
+		h2s := &http2.Server{IdleTimeout: srv.IdleTimeout}
+		if err := http2.ConfigureServer(srv, h2s); err != nil {
+			debugPrintError(err)
+		}
+		srv.ConnContext = withConn
+		srv.Handler = engine.h2cConns.handler(h2c.NewHandler(srv.Handler, h2s))
+	}
+	return srv
+}
+
+// connContextKey context 中 net.Conn 的 key
+type connContextKey struct{}
+
+// withConn 把连接保存到 context 中，用于 http.Server.ConnContext
+func withConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// h2cConns 正在使用的 h2c 连接
+//	h2c 连接会被 Hijack，http.Server.Shutdown 不会等待，由 Engine.Shutdown 等待
+type h2cConns struct {
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// handler 记录 h 处理的 h2c 连接，h 返回时 h2c 连接已关闭
+func (t *h2cConns) handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, ok := req.Context().Value(connContextKey{}).(net.Conn)
+		if !ok || !isH2C(req) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		t.mu.Lock()
+		if t.conns == nil {
+			t.conns = make(map[net.Conn]struct{})
+		}
+		t.conns[conn] = struct{}{}
+		t.wg.Add(1)
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			delete(t.conns, conn)
+			t.mu.Unlock()
+			t.wg.Done()
+		}()
+		h.ServeHTTP(w, req)
+	})
+}
+
+// wait 等待所有 h2c 连接关闭，ctx 超时后强制关闭
+func (t *h2cConns) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		for conn := range t.conns {
+			conn.Close()
+		}
+		t.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// isH2C 是否为 h2c 请求：prior knowledge 的 PRI 请求或 Upgrade: h2c
+func isH2C(req *http.Request) bool {
+	if req.Method == "PRI" && req.URL.Path == "*" && req.ProtoMajor == 2 {
+		return true
+	}
+	return strings.EqualFold(req.Header.Get("Upgrade"), "h2c")
+}
+
+// serve 运行 fn 并阻塞
+//	服务被 Shutdown 关闭时，等待平滑关闭完成后返回
+func (engine *Engine) serve(srv *http.Server, fn func() error) error {
+	engine.mu.Lock()
+	if engine.shutting {
+		engine.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	engine.servers = append(engine.servers, srv)
+	engine.mu.Unlock()
+
+	if err := fn(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	<-engine.done
+	return engine.shutdownErr
+}
+
+// handler 返回处理请求的 http.Handler
+//	挂载了 grpc 服务时，HTTP/2 的 grpc 请求交给 grpc 服务处理
+func (engine *Engine) handler() http.Handler {
+	if engine.grpcServer == nil {
+		return engine
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.ProtoMajor == 2 && strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+			engine.grpcServer.ServeHTTP(w, req)
+			return
+		}
+		engine.ServeHTTP(w, req)
+	})
+}
+
+// serveListener 使用 listener 提供服务
+func (engine *Engine) serveListener(listener net.Listener) error {
+	srv := engine.newServer(listener.Addr().String(), nil)
+	return engine.serve(srv, func() error {
+		return srv.Serve(listener)
+	})
+}
+
+// redirectToTLS 返回一个将请求跳转到 tlsAddr 所在 HTTPS 服务的 http.Handler
+func redirectToTLS(tlsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.Trim(host, "[]")
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		target := "https://" + host + req.URL.RequestURI()
+		http.Redirect(w, req, target, http.StatusMovedPermanently)
+	})
+}
+
+// handleSignal 处理信号
+//	GracefulOn: 收到 SIGINT/SIGTERM 时平滑关闭服务
+//	HotRestartOn: 收到 SIGHUP 时启动新进程接管 listener，并平滑关闭当前进程
+func (engine *Engine) handleSignal() {
+	var signals []os.Signal
+	if engine.GracefulOn {
+		signals = append(signals, syscall.SIGINT, syscall.SIGTERM)
+	}
+	if engine.HotRestartOn {
+		signals = append(signals, syscall.SIGHUP)
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case sig := <-ch:
+			debugPrint("[%s] Received signal: %v", engine.AppName, sig)
+			if sig == syscall.SIGHUP {
+				if err := engine.restart(); err != nil {
+					debugPrintError(err)
+					continue
+				}
+			}
+		case <-engine.done:
+			return
+		}
+		break
+	}
+
+	timeout := engine.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		debugPrintError(err)
+	}
+}
@@ -0,0 +1,396 @@
+package gow
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startServer 在随机端口上运行 r，返回地址和 Run 的返回值
+func startServer(t *testing.T, r *Engine) (string, chan error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- r.RunListener(ln)
+	}()
+	addr := ln.Addr().String()
+	// 等待服务注册到 engine.servers
+	for i := 0; i < 100; i++ {
+		r.mu.Lock()
+		n := len(r.servers)
+		r.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return addr, errCh
+}
+
+// h2cClient 使用 prior knowledge 的 h2c 客户端
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+}
+
+func TestShutdown_H2C(t *testing.T) {
+	r := New()
+	r.H2COn = true
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	r.GET("/slow", func(c *Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String(c.Req.Proto)
+		close(finished)
+	})
+	var hookAfterRequest bool
+	r.OnShutdown(func() error {
+		select {
+		case <-finished:
+			hookAfterRequest = true
+		default:
+		}
+		return nil
+	})
+	addr, errCh := startServer(t, r)
+
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := h2cClient().Get("http://" + addr + "/slow")
+		if err != nil {
+			respCh <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		respCh <- string(b)
+	}()
+	<-started
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Shutdown returned before the h2c request finished")
+	}
+	if !hookAfterRequest {
+		t.Error("OnShutdown ran before the h2c request finished")
+	}
+	if got := <-respCh; got != "HTTP/2.0" {
+		t.Errorf("response = %q, want HTTP/2.0", got)
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("RunListener() = %v", err)
+	}
+}
+
+func TestShutdown_H2CTimeout(t *testing.T) {
+	r := New()
+	r.H2COn = true
+	started := make(chan struct{})
+	r.GET("/block", func(c *Context) {
+		close(started)
+		<-c.Req.Context().Done()
+	})
+	addr, _ := startServer(t, r)
+	go h2cClient().Get("http://" + addr + "/block")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown() = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestShutdown_WaitsForRequests(t *testing.T) {
+	r := New()
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	r.GET("/slow", func(c *Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String("done")
+		close(finished)
+	})
+	addr, errCh := startServer(t, r)
+
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			respCh <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		respCh <- string(b)
+	}()
+	<-started
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Shutdown returned before the request finished")
+	}
+	if got := <-respCh; got != "done" {
+		t.Errorf("response = %q, want done", got)
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("RunListener() = %v", err)
+	}
+	// 关闭后不再接收新连接
+	if _, err := http.Get("http://" + addr + "/slow"); err == nil {
+		t.Error("server still accepts connections after Shutdown")
+	}
+}
+
+func TestShutdown_Hooks(t *testing.T) {
+	r := New()
+	var calls []string
+	hookErr := errors.New("close db")
+	r.OnStart(func() error {
+		calls = append(calls, "start")
+		return nil
+	})
+	r.OnShutdown(func() error {
+		calls = append(calls, "shutdown1")
+		return hookErr
+	}, func() error {
+		calls = append(calls, "shutdown2")
+		return nil
+	})
+	_, errCh := startServer(t, r)
+
+	if err := r.Shutdown(context.Background()); err != hookErr {
+		t.Errorf("Shutdown() = %v, want %v", err, hookErr)
+	}
+	// 多次调用返回相同的结果，hook 只执行一次
+	if err := r.Shutdown(context.Background()); err != hookErr {
+		t.Errorf("second Shutdown() = %v, want %v", err, hookErr)
+	}
+	if err := <-errCh; err != hookErr {
+		t.Errorf("RunListener() = %v, want %v", err, hookErr)
+	}
+	if got, want := strings.Join(calls, ","), "start,shutdown1,shutdown2"; got != want {
+		t.Errorf("hooks = %s, want %s", got, want)
+	}
+}
+
+func TestRun_StartError(t *testing.T) {
+	r := New()
+	startErr := errors.New("connect db")
+	var second bool
+	r.OnStart(func() error {
+		return startErr
+	}, func() error {
+		second = true
+		return nil
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.RunListener(ln)
+	}()
+	select {
+	case err = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunListener did not return after OnStart failed")
+	}
+	if err != startErr {
+		t.Errorf("RunListener() = %v, want %v", err, startErr)
+	}
+	if second {
+		t.Error("OnStart hooks after the failed one should not run")
+	}
+	if err = r.Run("127.0.0.1:0"); err != startErr {
+		t.Errorf("Run() = %v, want %v", err, startErr)
+	}
+}
+
+func TestNewServer_Timeouts(t *testing.T) {
+	type timeouts struct {
+		readHeader time.Duration
+		read       time.Duration
+		idle       time.Duration
+		maxHeader  int
+	}
+	tests := []struct {
+		mode string
+		set  timeouts
+		want timeouts
+	}{
+		{devMode, timeouts{}, timeouts{}},
+		// prod 模式下只设置不会中断长连接的默认值
+		{prodMode, timeouts{}, timeouts{prodReadHeaderTimeout, 0, prodIdleTimeout, prodMaxHeaderBytes}},
+		{prodMode, timeouts{time.Second, 5 * time.Second, time.Minute, 4096}, timeouts{time.Second, 5 * time.Second, time.Minute, 4096}},
+	}
+	for _, tt := range tests {
+		r := New()
+		r.RunMode = tt.mode
+		r.ReadHeaderTimeout = tt.set.readHeader
+		r.ReadTimeout = tt.set.read
+		r.IdleTimeout = tt.set.idle
+		r.MaxHeaderBytes = tt.set.maxHeader
+		srv := r.newServer(":8080", nil)
+		got := timeouts{srv.ReadHeaderTimeout, srv.ReadTimeout, srv.IdleTimeout, srv.MaxHeaderBytes}
+		if got != tt.want || srv.WriteTimeout != 0 {
+			t.Errorf("%s: got %+v WriteTimeout=%v, want %+v", tt.mode, got, srv.WriteTimeout, tt.want)
+		}
+	}
+}
+
+func TestRunUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gow-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "gow.sock")
+
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.String("unix")
+	})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- r.RunUnix(file)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", file)
+		},
+	}}
+	var resp *http.Response
+	for i := 0; i < 100; i++ {
+		if resp, err = client.Get("http://unix/"); err == nil {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "unix" {
+		t.Errorf("response = %q, want unix", b)
+	}
+
+	if err = r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-errCh; err != nil {
+		t.Errorf("RunUnix() = %v", err)
+	}
+	if _, err = os.Stat(file); !os.IsNotExist(err) {
+		t.Error("socket file is not removed after Shutdown")
+	}
+}
+
+func TestRedirectToTLS(t *testing.T) {
+	tests := []struct {
+		tlsAddr string
+		target  string
+		want    string
+	}{
+		{":443", "http://example.com/a?b=1", "https://example.com/a?b=1"},
+		{":443", "http://example.com:80/a", "https://example.com/a"},
+		{":8443", "http://example.com:8080/a", "https://example.com:8443/a"},
+		{"127.0.0.1:8443", "http://[::1]:8080/", "https://[::1]:8443/"},
+		{":443", "http://[::1]/", "https://[::1]/"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		redirectToTLS(tt.tlsAddr).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != tt.want {
+			t.Errorf("redirectToTLS(%q) %s: got %d %q, want %q", tt.tlsAddr, tt.target, w.Code, w.Header().Get("Location"), tt.want)
+		}
+	}
+}
+
+func TestMountGRPC(t *testing.T) {
+	gs := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("gow", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(gs, hs)
+
+	r := New()
+	r.MountGRPC(gs)
+	r.GET("/ping", func(c *Context) {
+		c.String("pong " + c.Req.Proto)
+	})
+	addr, errCh := startServer(t, r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "gow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("grpc status = %v, want SERVING", resp.Status)
+	}
+
+	// 同一个端口上的 HTTP/1.1 和 h2c 请求交给路由处理
+	for _, client := range []*http.Client{http.DefaultClient, h2cClient()} {
+		res, err := client.Get("http://" + addr + "/ping")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if !strings.HasPrefix(string(b), "pong HTTP/") {
+			t.Errorf("route response = %q", b)
+		}
+	}
+
+	if err = r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-errCh; err != nil {
+		t.Errorf("RunListener() = %v", err)
+	}
+	// GracefulStop 后 grpc 服务不再处理请求
+	if _, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "gow"}, grpc.WaitForReady(false)); err == nil {
+		t.Error("grpc server still serves after Shutdown")
+	}
+}
@@ -1,19 +1,66 @@
 package gow
 
 import (
+	"fmt"
+	"github.com/gkzy/gow/lib/config"
+	"github.com/gkzy/gow/lib/redis"
 	"github.com/gkzy/gow/session"
 )
 
 var (
 	cookieName     = "gow_session_id"
 	sessionManager *session.Manager
-	sessionID      string
 )
 
 // InitSession   init gow session
 //	before using session,please call this function
+//	根据 app.conf 中 [session] 的配置选择存储方式，没有配置时使用内存
+//		[session]
+//		store = redis          ; memory|redis|file|cookie
+//		cookie_name = gow_session_id
+//		cookie_domain =
+//		cookie_secure = false
+//		cookie_http_only = true
+//		cookie_same_site = lax ; lax|strict|none
+//		max_life_time = 3600   ; 空闲超时，单位为秒
+//		absolute_timeout = 0   ; 绝对超时，单位为秒，0为不限制
+//		redis_host = 127.0.0.1 ; 配置时使用独立的连接池，不配置时使用 redis.InitRDSClient 初始化的连接
+//		redis_port = 6379
+//		redis_password =
+//		redis_db = 0
+//		redis_prefix = session:
+//		file_dir = ./sessions
+//		cookie_hash_key =      ; cookie 存储时必须配置
+//		cookie_block_key =     ; 16/24/32位，配置后加密
 func InitSession() {
-	sessionManager = session.NewSessionManager(cookieName, 3600)
+	store, err := newSessionStore()
+	if err != nil {
+		panic(err)
+	}
+	InitSessionStore(store)
+}
+
+// InitSessionStore init gow session with store
+//	不传 opts 时，使用 app.conf 中 [session] 的配置
+//		store, _ := session.NewFileStore("./sessions")
+//		opts := session.DefaultOptions()
+//		opts.Secure = true
+//		gow.InitSessionStore(store, opts)
+func InitSessionStore(store session.Store, opts ...session.Options) {
+	var opt session.Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	} else {
+		opt = sessionOptions()
+	}
+	sessionManager = session.NewManagerWithOptions(store, opt)
+}
+
+// SessionManager return the session manager
+//	修改密码或封禁用户后，注销该用户在所有设备上的 session
+//		gow.SessionManager().RevokeUser(uid)
+func SessionManager() *session.Manager {
+	return sessionManager
 }
 
 // Session session middleware
@@ -24,15 +71,71 @@ func Session() HandlerFunc {
 		if sessionManager == nil {
 			panic("please call gow.InitSession()")
 		}
-		sessionID = sessionManager.Start(c.Writer, c.Req)
-		sessionManager.Extension(c.Writer, c.Req)
+		sess, err := sessionManager.Load(c.Writer, c.Req)
+		if err != nil {
+			debugPrintError(err)
+		}
+		c.session = sess
 		c.Next()
 	}
 }
 
+// sessionOptions 根据配置返回 session 选项
+func sessionOptions() session.Options {
+	return session.Options{
+		CookieName:      config.DefaultString("session::cookie_name", cookieName),
+		Path:            config.DefaultString("session::cookie_path", "/"),
+		Domain:          config.GetString("session::cookie_domain"),
+		Secure:          config.DefaultBool("session::cookie_secure", false),
+		HttpOnly:        config.DefaultBool("session::cookie_http_only", true),
+		SameSite:        session.ParseSameSite(config.GetString("session::cookie_same_site")),
+		IdleTimeout:     config.DefaultInt64("session::max_life_time", 3600),
+		AbsoluteTimeout: config.DefaultInt64("session::absolute_timeout", 0),
+	}
+}
+
+// newSessionStore 根据配置返回 session store
+func newSessionStore() (session.Store, error) {
+	switch store := config.DefaultString("session::store", "memory"); store {
+	case "memory":
+		return session.NewMemoryStore(), nil
+	case "redis":
+		prefix := config.GetString("session::redis_prefix")
+		if host := config.GetString("session::redis_host"); host != "" {
+			// 使用独立的连接池，不覆盖应用通过 redis.InitRDSClient 初始化的连接
+			rc, err := redis.NewRDSCommon(&redis.RDSConfig{
+				Host:      host,
+				Port:      config.DefaultInt("session::redis_port", 6379),
+				Password:  config.GetString("session::redis_password"),
+				DB:        config.DefaultInt("session::redis_db", 0),
+				MaxIdle:   config.DefaultInt("session::redis_max_idle", 10),
+				MaxActive: config.DefaultInt("session::redis_max_active", 100),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return session.NewRedisStoreWithClient(prefix, rc), nil
+		}
+		return session.NewRedisStore(prefix), nil
+	case "file":
+		return session.NewFileStore(config.GetString("session::file_dir"))
+	case "cookie":
+		return session.NewCookieStore(
+			config.GetString("session::cookie_store_name"),
+			config.GetString("session::cookie_hash_key"),
+			config.GetString("session::cookie_block_key"),
+		)
+	default:
+		return nil, fmt.Errorf("unknown session store: %s", store)
+	}
+}
+
 //getSession getSession
-func getSession(key interface{}) interface{} {
-	v, ok := sessionManager.Get(sessionID, key)
+func getSession(c *Context, key interface{}) interface{} {
+	if c.session == nil {
+		return nil
+	}
+	v, ok := c.session.Get(key)
 	if ok {
 		return v
 	}
@@ -40,11 +143,21 @@ func getSession(key interface{}) interface{} {
 }
 
 //setSession setSession
-func setSession(key, value interface{}) {
-	sessionManager.Set(sessionID, key, value)
+func setSession(c *Context, key, value interface{}) {
+	if c.session == nil {
+		return
+	}
+	if err := c.session.Set(key, value); err != nil {
+		debugPrintError(err)
+	}
 }
 
 //deleteSession deleteSession
-func deleteSession(key interface{}) {
-	sessionManager.Delete(sessionID, key)
+func deleteSession(c *Context, key interface{}) {
+	if c.session == nil {
+		return
+	}
+	if err := c.session.Delete(key); err != nil {
+		debugPrintError(err)
+	}
 }
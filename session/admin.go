@@ -0,0 +1,138 @@
+package session
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// userKey 关联的 userID
+	userKey metaKey = "user"
+	// ipKey 关联 userID 时的客户端 IP
+	ipKey metaKey = "ip"
+	// userAgentKey 关联 userID 时的 User-Agent
+	userAgentKey metaKey = "user_agent"
+)
+
+// ErrIndexNotSupported store 没有实现 Indexer
+var ErrIndexNotSupported = errors.New("session: the store does not support listing sessions by user")
+
+// Info 用户的一个 session
+type Info struct {
+	SessionID  string
+	UserID     string
+	CreatedAt  time.Time
+	LastAccess time.Time
+	IP         string
+	UserAgent  string
+}
+
+// Indexer 可以按 userID 查询 session 的 Store
+//	MemoryStore 已实现；共享存储(如 redis)可按 userID 保存一个 sessionID -> Info 的索引来实现
+//	Store.Destroy 时需要同时删除索引；Index 可以返回已过期的 session，由 Manager.UserSessions 过滤
+type Indexer interface {
+	// Bind 保存 session 与 userID 的关联
+	Bind(info *Info) error
+
+	// Touch 更新最后访问时间
+	Touch(userID, sessionID string, t time.Time) error
+
+	// Index 返回 userID 的所有 session
+	Index(userID string) ([]*Info, error)
+}
+
+// BindUser 把 session 关联到 userID
+//	关联后，可以通过 UserSessions 查询，通过 Revoke RevokeUser 注销
+func (m *Manager) BindUser(s *Session, userID, ip, userAgent string) error {
+	indexer, ok := m.store.(Indexer)
+	if !ok {
+		return ErrIndexNotSupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[userKey] = userID
+	s.values[ipKey] = ip
+	s.values[userAgentKey] = userAgent
+	set := map[interface{}]interface{}{userKey: userID, ipKey: ip, userAgentKey: userAgent}
+	if err := m.save(s.w, s.id, s.values, set, nil); err != nil {
+		return err
+	}
+	return indexer.Bind(s.info())
+}
+
+// UserSessions 返回 userID 所有有效的 session
+//	已超过空闲超时或绝对超时、但还没有被 GC 删除的 session 不会返回
+func (m *Manager) UserSessions(userID string) ([]*Info, error) {
+	indexer, ok := m.store.(Indexer)
+	if !ok {
+		return nil, ErrIndexNotSupported
+	}
+	list, err := indexer.Index(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	ret := list[:0]
+	for _, info := range list {
+		if info.LastAccess.Unix()+m.opts.IdleTimeout < now {
+			continue
+		}
+		if m.opts.AbsoluteTimeout > 0 && info.CreatedAt.Unix()+m.opts.AbsoluteTimeout < now {
+			continue
+		}
+		ret = append(ret, info)
+	}
+	return ret, nil
+}
+
+// Revoke 注销 userID 的一个 session
+func (m *Manager) Revoke(userID, sessionID string) error {
+	list, err := m.UserSessions(userID)
+	if err != nil {
+		return err
+	}
+	for _, info := range list {
+		if info.SessionID == sessionID {
+			return m.store.Destroy(nil, sessionID)
+		}
+	}
+	return nil
+}
+
+// RevokeUser 注销 userID 的所有 session，返回注销的数量
+//	修改密码、封禁用户后调用，使其在所有设备上退出登录
+func (m *Manager) RevokeUser(userID string) (int, error) {
+	list, err := m.UserSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+	for i, info := range list {
+		if err = m.store.Destroy(nil, info.SessionID); err != nil {
+			return i, err
+		}
+	}
+	return len(list), nil
+}
+
+// UserID 返回关联的 userID
+func (s *Session) UserID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	uid, _ := s.values[userKey].(string)
+	return uid
+}
+
+// info 返回 session 的 Info，需要持有锁
+func (s *Session) info() *Info {
+	created, _ := s.values[createdKey].(int64)
+	info := &Info{
+		SessionID:  s.id,
+		CreatedAt:  time.Unix(created, 0),
+		LastAccess: time.Now(),
+	}
+	info.UserID, _ = s.values[userKey].(string)
+	info.IP, _ = s.values[ipKey].(string)
+	info.UserAgent, _ = s.values[userAgentKey].(string)
+	return info
+}
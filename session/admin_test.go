@@ -0,0 +1,126 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestManager_RevokeUser(t *testing.T) {
+	mgr := NewManager("sid", 3600, NewMemoryStore())
+
+	var cookies []*http.Cookie
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		s, err := mgr.Load(w, httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mgr.BindUser(s, "u1", "127.0.0.1", "test"); err != nil {
+			t.Fatal(err)
+		}
+		cookies = append(cookies, w.Result().Cookies()[0])
+	}
+
+	list, err := mgr.UserSessions("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("UserSessions got %d sessions, want 3", len(list))
+	}
+	if list[0].IP != "127.0.0.1" || list[0].UserAgent != "test" {
+		t.Fatalf("unexpected info: %+v", list[0])
+	}
+
+	if err = mgr.Revoke("u1", cookies[0].Value); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ = mgr.UserSessions("u1"); len(list) != 2 {
+		t.Fatalf("after Revoke got %d sessions, want 2", len(list))
+	}
+
+	n, err := mgr.RevokeUser("u1")
+	if err != nil || n != 2 {
+		t.Fatalf("RevokeUser got %d, %v", n, err)
+	}
+	for _, ck := range cookies {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(ck)
+		s, _ := mgr.Load(httptest.NewRecorder(), r)
+		if s.ID() == ck.Value || s.UserID() != "" {
+			t.Fatalf("session %s is still valid after RevokeUser", ck.Value)
+		}
+	}
+}
+
+func TestSession_RegenerateKeepsUser(t *testing.T) {
+	mgr := NewManager("sid", 3600, NewMemoryStore())
+	s, _ := mgr.Load(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	mgr.BindUser(s, "u1", "", "")
+	old := s.ID()
+	if err := s.Regenerate(); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := mgr.UserSessions("u1")
+	if len(list) != 1 || list[0].SessionID != s.ID() || s.ID() == old {
+		t.Fatalf("unexpected sessions after Regenerate: %+v", list)
+	}
+}
+
+// TestManager_UserSessionsExpired 超时但还没有被 GC 删除的 session 不会返回
+func TestManager_UserSessionsExpired(t *testing.T) {
+	opts := DefaultOptions()
+	opts.IdleTimeout = 600
+	opts.AbsoluteTimeout = 3600
+	store := NewMemoryStore()
+	mgr := NewManagerWithOptions(store, opts)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		s, _ := mgr.Load(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		if err := mgr.BindUser(s, "u1", "127.0.0.1", "test"); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, s.ID())
+	}
+	// 第一个空闲超时，第二个绝对超时
+	store.mu.Lock()
+	idle := time.Now().Add(-700 * time.Second)
+	store.session[ids[0]].lastTimeAccessed = idle
+	store.session[ids[0]].info.LastAccess = idle
+	store.session[ids[1]].info.CreatedAt = time.Now().Add(-2 * time.Hour)
+	store.mu.Unlock()
+
+	list, err := mgr.UserSessions("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].SessionID != ids[2] {
+		t.Fatalf("UserSessions got %+v, want only %s", list, ids[2])
+	}
+}
+
+// TestManager_RevokeThenSet 注销后，仍在处理的请求修改 session 不会恢复 session
+func TestManager_RevokeThenSet(t *testing.T) {
+	store := NewMemoryStore()
+	mgr := NewManager("sid", 3600, store)
+	s, _ := mgr.Load(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err := mgr.BindUser(s, "u1", "127.0.0.1", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := mgr.RevokeUser("u1"); err != nil || n != 1 {
+		t.Fatalf("RevokeUser got %d, %v", n, err)
+	}
+	if err := s.Set("cart", 3); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := store.Read(nil, s.ID(), 3600); v != nil {
+		t.Fatalf("revoked session was restored: %v", v)
+	}
+	if list, _ := mgr.UserSessions("u1"); len(list) != 0 {
+		t.Fatalf("UserSessions got %d sessions after RevokeUser", len(list))
+	}
+}
@@ -0,0 +1,173 @@
+package session
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/gkzy/gow/lib/util"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCookieStoreName = "gow_session"
+	// maxCookieSize 浏览器对单个 cookie 的大小限制
+	maxCookieSize = 4000
+)
+
+var (
+	// ErrCookieTooLarge session 数据超过 cookie 的大小限制
+	ErrCookieTooLarge = errors.New("session: the encoded session is too large for a cookie")
+	errInvalidCookie  = errors.New("session: invalid cookie")
+)
+
+// cookiePayload 写入 cookie 的数据
+type cookiePayload struct {
+	ID      string
+	Expires int64
+	Values  map[interface{}]interface{}
+}
+
+// CookieStore 把 session 数据签名(HMAC-SHA256)后存储在 cookie 中
+//	设置 blockKey 时，同时使用 AES 加密
+//	cookie 大小有限，只适合存储少量数据；修改 session 需要在写入响应之前
+//	cookie 的属性使用 Manager 的 Options
+type CookieStore struct {
+	name     string
+	hashKey  []byte
+	blockKey string
+	opts     Options
+}
+
+// NewCookieStore return a cookie store
+//	name 为存储数据的 cookie 名称，默认为 gow_session
+//	hashKey 用于签名，不能为空
+//	blockKey 用于 AES 加密，长度为 16 24 或 32，为空时不加密
+func NewCookieStore(name, hashKey, blockKey string) (*CookieStore, error) {
+	if name == "" {
+		name = defaultCookieStoreName
+	}
+	if hashKey == "" {
+		return nil, errors.New("session: cookie store needs a hash key")
+	}
+	switch len(blockKey) {
+	case 0, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("session: invalid block key length %d", len(blockKey))
+	}
+	return &CookieStore{
+		name:     name,
+		hashKey:  []byte(hashKey),
+		blockKey: blockKey,
+		opts:     DefaultOptions(),
+	}, nil
+}
+
+// setOptions 使用 Manager 的 cookie 属性
+func (m *CookieStore) setOptions(opts Options) {
+	m.opts = opts
+}
+
+// Read Read
+func (m *CookieStore) Read(r *http.Request, sessionID string, maxLifeTime int64) (map[interface{}]interface{}, error) {
+	if r == nil {
+		return nil, ErrNoRequest
+	}
+	ck, err := r.Cookie(m.name)
+	if err != nil || ck.Value == "" {
+		return nil, nil
+	}
+	payload, err := m.decode(ck.Value)
+	if err != nil || payload.ID != sessionID || payload.Expires < time.Now().Unix() {
+		// 签名错误、不属于当前 session 或已过期，都当做不存在
+		return nil, nil
+	}
+	if payload.Values == nil {
+		payload.Values = make(map[interface{}]interface{})
+	}
+	return payload.Values, nil
+}
+
+// Write Write
+func (m *CookieStore) Write(w http.ResponseWriter, sessionID string, values map[interface{}]interface{}, maxLifeTime int64) error {
+	if w == nil {
+		return ErrNoWriter
+	}
+	value, err := m.encode(&cookiePayload{
+		ID:      sessionID,
+		Expires: time.Now().Unix() + maxLifeTime,
+		Values:  values,
+	})
+	if err != nil {
+		return err
+	}
+	if len(value) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	http.SetCookie(w, m.opts.cookie(m.name, value, int(maxLifeTime)))
+	return nil
+}
+
+// Destroy Destroy
+func (m *CookieStore) Destroy(w http.ResponseWriter, sessionID string) error {
+	if w == nil {
+		return ErrNoWriter
+	}
+	http.SetCookie(w, m.opts.cookie(m.name, "", -1))
+	return nil
+}
+
+// GC 数据在客户端，不需要处理
+func (m *CookieStore) GC(maxLifeTime int64) {}
+
+// encode payload -> [aes] -> base64 -> payload.signature
+func (m *CookieStore) encode(payload *cookiePayload) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(payload); err != nil {
+		return "", err
+	}
+	data := base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	if m.blockKey != "" {
+		data = util.AESEncrypt(data, m.blockKey)
+	}
+	return data + "." + m.sign(data), nil
+}
+
+// decode 校验签名并解码
+func (m *CookieStore) decode(value string) (*cookiePayload, error) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return nil, errInvalidCookie
+	}
+	data, sig := value[:i], value[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(m.sign(data))) {
+		return nil, errInvalidCookie
+	}
+	if m.blockKey != "" {
+		var err error
+		if data, err = util.AESDecrypt(data, m.blockKey); err != nil {
+			return nil, err
+		}
+	}
+	b, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	payload := new(cookiePayload)
+	if err = gob.NewDecoder(bytes.NewReader(b)).Decode(payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// sign HMAC-SHA256
+func (m *CookieStore) sign(data string) string {
+	mac := hmac.New(sha256.New, m.hashKey)
+	mac.Write([]byte(m.name + "|" + data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
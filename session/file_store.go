@@ -0,0 +1,165 @@
+package session
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFileDir = "./sessions"
+)
+
+// FileStore 使用本地文件存储 session，重启后不丢失
+//	每个 session 一个文件，文件的修改时间为最后访问时间
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore return a file store
+//	dir 为存储目录，默认为 ./sessions
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		dir = defaultFileDir
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Read Read
+func (m *FileStore) Read(r *http.Request, sessionID string, maxLifeTime int64) (map[interface{}]interface{}, error) {
+	file, err := m.fileName(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fi, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fi.ModTime().Unix()+maxLifeTime < time.Now().Unix() {
+		os.Remove(file)
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	os.Chtimes(file, now, now)
+	return decodeValues(b)
+}
+
+// Write Write
+func (m *FileStore) Write(w http.ResponseWriter, sessionID string, values map[interface{}]interface{}, maxLifeTime int64) error {
+	file, err := m.fileName(sessionID)
+	if err != nil {
+		return err
+	}
+	b, err := encodeValues(values)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.writeFile(file, b)
+}
+
+// Update 读取文件，只修改 set 和 del 中的 key 后写回，文件不存在或已过期时不修改
+//	只在进程内加锁，多个进程共用一个目录时仍可能互相覆盖
+func (m *FileStore) Update(w http.ResponseWriter, sessionID string, set map[interface{}]interface{}, del []interface{}, maxLifeTime int64) error {
+	file, err := m.fileName(sessionID)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fi, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Unix()+maxLifeTime < time.Now().Unix() {
+		os.Remove(file)
+		return nil
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	values, err := decodeValues(b)
+	if err != nil {
+		return err
+	}
+	applyValues(values, set, del)
+	if b, err = encodeValues(values); err != nil {
+		return err
+	}
+	return m.writeFile(file, b)
+}
+
+// writeFile 先写入临时文件再重命名，需要持有锁
+func (m *FileStore) writeFile(file string, b []byte) error {
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// Destroy Destroy
+func (m *FileStore) Destroy(w http.ResponseWriter, sessionID string) error {
+	file, err := m.fileName(sessionID)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err = os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GC GC
+func (m *FileStore) GC(maxLifeTime int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files, err := ioutil.ReadDir(m.dir)
+	if err != nil {
+		return
+	}
+	for _, fi := range files {
+		if fi.IsDir() {
+			continue
+		}
+		if fi.ModTime().Unix()+maxLifeTime < time.Now().Unix() {
+			os.Remove(filepath.Join(m.dir, fi.Name()))
+		}
+	}
+}
+
+// fileName 返回 sessionID 对应的文件
+func (m *FileStore) fileName(sessionID string) (string, error) {
+	if sessionID == "" || strings.ContainsAny(sessionID, `/\.`) {
+		return "", errors.New("session: invalid session id")
+	}
+	return filepath.Join(m.dir, sessionID), nil
+}
@@ -12,160 +12,236 @@ import (
 )
 
 type (
+	// Session 当前请求的 session
+	//	修改后立即保存到 Store，Store 实现了 Updater 时只保存修改的 key
 	Session struct {
-		sessionID        string
-		lastTimeAccessed time.Time
-		values           map[interface{}]interface{}
+		manager *Manager
+		w       http.ResponseWriter
+		id      string
+		mu      sync.RWMutex
+		values  map[interface{}]interface{}
 	}
 
 	Manager struct {
-		cookieName  string
-		mu          sync.RWMutex
-		maxLifeTime int64
-		session     map[string]*Session
+		opts  Options
+		store Store
 	}
 )
 
 // NewSessionManager return a session manager
+//	使用内存存储
 func NewSessionManager(cookieName string, maxLifeTime int64) *Manager {
+	return NewManager(cookieName, maxLifeTime, NewMemoryStore())
+}
+
+// NewManager return a session manager with store
+//		store, _ := session.NewFileStore("./sessions")
+//		mgr := session.NewManager("gow_session_id", 3600, store)
+func NewManager(cookieName string, maxLifeTime int64, store Store) *Manager {
+	opts := DefaultOptions()
+	opts.CookieName = cookieName
+	opts.IdleTimeout = maxLifeTime
+	return NewManagerWithOptions(store, opts)
+}
+
+// NewManagerWithOptions return a session manager with store and options
+//		opts := session.DefaultOptions()
+//		opts.Secure = true
+//		opts.SameSite = http.SameSiteLaxMode
+//		opts.AbsoluteTimeout = 7 * 24 * 3600
+//		mgr := session.NewManagerWithOptions(session.NewMemoryStore(), opts)
+func NewManagerWithOptions(store Store, opts Options) *Manager {
+	opts = opts.prepare()
+	if setter, ok := store.(optionsSetter); ok {
+		setter.setOptions(opts)
+	}
 	mgr := &Manager{
-		cookieName:  cookieName,
-		maxLifeTime: maxLifeTime,
-		session:     nil,
-		mu:          sync.RWMutex{},
+		opts:  opts,
+		store: store,
 	}
 	go mgr.GC()
 	return mgr
 }
 
-//Start Start session return sessionID
-func (m *Manager) Start(w http.ResponseWriter, r *http.Request) string {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	var (
-		sessionID string
-	)
+// Options return the session options
+func (m *Manager) Options() Options {
+	return m.opts
+}
 
-	ck, err := r.Cookie(m.cookieName)
-	if err == nil && ck != nil && ck.Value != "" {
-		sessionID = ck.Value
-	}
+// Store return the session store
+func (m *Manager) Store() Store {
+	return m.store
+}
 
-	if sessionID != "" && m.session != nil {
-		return sessionID
+// Load 返回当前请求的 session
+//	cookie 中的 sessionID 有效且没有超时时，读取已有的 session，并重新写入 cookie 以延长有效期
+//	否则创建新的 session，并写入 cookie
+func (m *Manager) Load(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	ck, err := r.Cookie(m.opts.CookieName)
+	if err == nil && ck != nil && validSessionID(ck.Value) {
+		values, err := m.store.Read(r, ck.Value, m.opts.IdleTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if values != nil && !m.expired(values) {
+			if uid, ok := values[userKey].(string); ok {
+				if indexer, ok := m.store.(Indexer); ok {
+					indexer.Touch(uid, ck.Value, time.Now())
+				}
+			}
+			if err = m.refresh(w, ck.Value, values); err != nil {
+				return nil, err
+			}
+			return &Session{manager: m, w: w, id: ck.Value, values: values}, nil
+		}
+		if values != nil {
+			m.store.Destroy(w, ck.Value)
+		}
 	}
 
-	sessionID = url.QueryEscape(m.makeNewSessionID())
-	session := &Session{
-		sessionID:        sessionID,
-		lastTimeAccessed: time.Now(),
-		values:           make(map[interface{}]interface{}),
+	values := map[interface{}]interface{}{
+		createdKey: time.Now().Unix(),
 	}
-
-	m.session = make(map[string]*Session)
-	m.session[sessionID] = session
-
-	cookie := http.Cookie{
-		Name:     m.cookieName,
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   int(m.maxLifeTime),
+	sessionID, err := m.create(w, values)
+	if err != nil {
+		return nil, err
 	}
-	http.SetCookie(w, &cookie)
+	return &Session{manager: m, w: w, id: sessionID, values: values}, nil
+}
 
-	return sessionID
+//Start Start session return sessionID
+//	cookie 中的 sessionID 有效时，更新访问时间并返回
+//	否则创建新的 session，并写入 cookie
+func (m *Manager) Start(w http.ResponseWriter, r *http.Request) string {
+	session, err := m.Load(w, r)
+	if err != nil {
+		return ""
+	}
+	return session.id
 }
 
 // End end session
-//	delete sessionID from cookie and map
+//	delete sessionID from cookie and store
 func (m *Manager) End(w http.ResponseWriter, r *http.Request) {
-	ck, err := r.Cookie(m.cookieName)
+	ck, err := r.Cookie(m.opts.CookieName)
 	if err != nil || ck.Value == "" {
 		return
-	} else {
-		m.mu.Lock()
-		defer m.mu.Unlock()
-
-		//delete map key
-		delete(m.session, ck.Value)
-		exp := time.Now()
-		cookie := http.Cookie{
-			Name:     m.cookieName,
-			Path:     "/",
-			HttpOnly: true,
-			Expires:  exp,
-			MaxAge:   -1,
-		}
-		http.SetCookie(w, &cookie)
 	}
+	m.store.Destroy(w, ck.Value)
+	http.SetCookie(w, m.opts.cookie(m.opts.CookieName, "", -1))
 }
 
 // Extension
 func (m *Manager) Extension(w http.ResponseWriter, r *http.Request) string {
-	ck, err := r.Cookie(m.cookieName)
+	ck, err := r.Cookie(m.opts.CookieName)
 	if err != nil || ck == nil {
 		return ""
 	}
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	sessionID := ck.Value
-
-	if session, ok := m.session[sessionID]; ok {
-		session.lastTimeAccessed = time.Now()
-		return sessionID
+	if values, _ := m.store.Read(r, ck.Value, m.opts.IdleTimeout); values != nil && !m.expired(values) {
+		return ck.Value
 	}
-
 	return ""
 }
 
 // Get get session value
+//	不支持 CookieStore，请使用 Load 返回的 *Session
 func (m *Manager) Get(sessionID string, key interface{}) (interface{}, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	if session, ok := m.session[sessionID]; ok {
-		if val, ok := session.values[key]; ok {
-			return val, ok
-		}
+	values, err := m.store.Read(nil, sessionID, m.opts.IdleTimeout)
+	if err != nil || values == nil {
+		return nil, false
 	}
-	return nil, false
+	val, ok := values[key]
+	return val, ok
 }
 
 // Set set session
+//	不支持 CookieStore，请使用 Load 返回的 *Session
 func (m *Manager) Set(sessionID string, key, value interface{}) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if session, ok := m.session[sessionID]; ok {
-		session.values[key] = value
+	values, err := m.store.Read(nil, sessionID, m.opts.IdleTimeout)
+	if err != nil || values == nil {
+		return
 	}
+	values[key] = value
+	m.save(nil, sessionID, values, map[interface{}]interface{}{key: value}, nil)
 }
 
 //Delete delete value by key
+//	不支持 CookieStore，请使用 Load 返回的 *Session
 func (m *Manager) Delete(sessionID string, key interface{}) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if session, ok := m.session[sessionID]; ok {
-		delete(session.values, key)
+	values, err := m.store.Read(nil, sessionID, m.opts.IdleTimeout)
+	if err != nil || values == nil {
+		return
 	}
+	delete(values, key)
+	m.save(nil, sessionID, values, nil, []interface{}{key})
 }
 
 // GC session gc
 func (m *Manager) GC() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.store.GC(m.opts.IdleTimeout)
+
+	time.AfterFunc(time.Duration(m.opts.IdleTimeout)*time.Second, func() {
+		m.GC()
+	})
+}
+
+// save 保存修改，store 实现了 Updater 时只写入 set 和 del，否则写入全部的 values
+func (m *Manager) save(w http.ResponseWriter, sessionID string, values, set map[interface{}]interface{}, del []interface{}) error {
+	if updater, ok := m.store.(Updater); ok {
+		return updater.Update(w, sessionID, set, del, m.opts.IdleTimeout)
+	}
+	return m.store.Write(w, sessionID, values, m.opts.IdleTimeout)
+}
 
-	for sessionID, session := range m.session {
-		if session.lastTimeAccessed.Unix()+m.maxLifeTime < time.Now().Unix() {
-			delete(m.session, sessionID)
+// refresh 每次访问时重新写入 cookie，使 cookie 的有效期与空闲超时一致
+//	否则 cookie 在创建后 IdleTimeout 过期，空闲超时变成了绝对超时
+//	CookieStore 的值保存在 cookie 中，同时重新写入
+func (m *Manager) refresh(w http.ResponseWriter, sessionID string, values map[interface{}]interface{}) error {
+	if w == nil {
+		return nil
+	}
+	if _, ok := m.store.(*CookieStore); ok {
+		if err := m.store.Write(w, sessionID, values, m.opts.IdleTimeout); err != nil {
+			return err
 		}
 	}
+	http.SetCookie(w, m.opts.cookie(m.opts.CookieName, sessionID, m.cookieMaxAge(values)))
+	return nil
+}
 
-	time.AfterFunc(time.Duration(m.maxLifeTime)*time.Second, func() {
-		m.GC()
-	})
+// cookieMaxAge cookie 的有效期，不超过绝对超时的剩余时间
+func (m *Manager) cookieMaxAge(values map[interface{}]interface{}) int {
+	maxAge := m.opts.IdleTimeout
+	if created, ok := values[createdKey].(int64); ok && m.opts.AbsoluteTimeout > 0 {
+		if left := created + m.opts.AbsoluteTimeout - time.Now().Unix(); left < maxAge {
+			maxAge = left
+		}
+	}
+	if maxAge < 1 {
+		maxAge = 1
+	}
+	return int(maxAge)
+}
+
+// create 使用新的 sessionID 保存 values，并写入 cookie
+func (m *Manager) create(w http.ResponseWriter, values map[interface{}]interface{}) (string, error) {
+	sessionID := url.QueryEscape(m.makeNewSessionID())
+	if err := m.store.Write(w, sessionID, values, m.opts.IdleTimeout); err != nil {
+		return "", err
+	}
+	if w != nil {
+		http.SetCookie(w, m.opts.cookie(m.opts.CookieName, sessionID, m.cookieMaxAge(values)))
+	}
+	return sessionID, nil
+}
+
+// expired 是否超过绝对超时
+func (m *Manager) expired(values map[interface{}]interface{}) bool {
+	if m.opts.AbsoluteTimeout <= 0 {
+		return false
+	}
+	created, ok := values[createdKey].(int64)
+	return ok && created+m.opts.AbsoluteTimeout < time.Now().Unix()
 }
 
 // makeNewSessionID
@@ -177,3 +253,77 @@ func (m *Manager) makeNewSessionID() string {
 	}
 	return base64.URLEncoding.EncodeToString(b)
 }
+
+// ID return session id
+func (s *Session) ID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.id
+}
+
+// Get get session value
+func (s *Session) Get(key interface{}) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.values[key]
+	return val, ok
+}
+
+// Set set session value and save
+func (s *Session) Set(key, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return s.manager.save(s.w, s.id, s.values, map[interface{}]interface{}{key: value}, nil)
+}
+
+// Delete delete value by key and save
+func (s *Session) Delete(key interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+	return s.manager.save(s.w, s.id, s.values, nil, []interface{}{key})
+}
+
+// Regenerate 更换 sessionID，保留 session 中的值
+//	登录成功后调用，防止 session fixation
+func (s *Session) Regenerate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.manager.store.Destroy(s.w, s.id); err != nil {
+		return err
+	}
+	sessionID, err := s.manager.create(s.w, s.values)
+	if err != nil {
+		return err
+	}
+	s.id = sessionID
+	if _, ok := s.values[userKey]; ok {
+		if indexer, ok := s.manager.store.(Indexer); ok {
+			return indexer.Bind(s.info())
+		}
+	}
+	return nil
+}
+
+// CreatedAt session 的创建时间
+func (s *Session) CreatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	created, _ := s.values[createdKey].(int64)
+	return time.Unix(created, 0)
+}
+
+// validSessionID 只接受 makeNewSessionID 生成的字符
+func validSessionID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '%') {
+			return false
+		}
+	}
+	return true
+}
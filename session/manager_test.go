@@ -0,0 +1,178 @@
+package session
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestManager_Parallel 多个客户端并发读写各自的 session
+//	go test -race
+func TestManager_Parallel(t *testing.T) {
+	m := NewSessionManager("gow_session_id", 3600)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			w := httptest.NewRecorder()
+			id := m.Start(w, httptest.NewRequest("GET", "/", nil))
+			m.Set(id, "uid", i)
+
+			for j := 0; j < 20; j++ {
+				req := httptest.NewRequest("GET", "/", nil)
+				req.AddCookie(&http.Cookie{Name: "gow_session_id", Value: id})
+				if got := m.Start(httptest.NewRecorder(), req); got != id {
+					t.Errorf("client %d: session id changed from %s to %s", i, id, got)
+					return
+				}
+				if v, _ := m.Get(id, "uid"); v != i {
+					t.Errorf("client %d: got uid %v", i, v)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestManager_UnknownID 未知的 sessionID 会创建新的 session
+func TestManager_UnknownID(t *testing.T) {
+	m := NewSessionManager("gow_session_id", 3600)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "gow_session_id", Value: "forged"})
+
+	w := httptest.NewRecorder()
+	id := m.Start(w, req)
+	if id == "forged" {
+		t.Fatal("forged session id was accepted")
+	}
+	if ck := w.Result().Cookies(); len(ck) == 0 || ck[0].Value != id {
+		t.Fatalf("new session id %s was not written to cookie", id)
+	}
+}
+
+// TestSession_Regenerate 更换 sessionID 后保留值，旧的 sessionID 失效
+func TestSession_Regenerate(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Secure = true
+	opts.SameSite = http.SameSiteLaxMode
+	m := NewManagerWithOptions(NewMemoryStore(), opts)
+
+	sess, _ := m.Load(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	sess.Set("uid", 1)
+	oldID := sess.ID()
+
+	w := httptest.NewRecorder()
+	sess.w = w
+	if err := sess.Regenerate(); err != nil {
+		t.Fatal(err)
+	}
+	if sess.ID() == oldID {
+		t.Fatal("session id was not changed")
+	}
+	if v, _ := m.Get(sess.ID(), "uid"); v != 1 {
+		t.Fatalf("got uid %v", v)
+	}
+	if _, ok := m.Get(oldID, "uid"); ok {
+		t.Fatal("old session id is still valid")
+	}
+	ck := w.Result().Cookies()
+	if len(ck) != 1 || ck[0].Value != sess.ID() || !ck[0].Secure || ck[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected cookie %v", ck)
+	}
+}
+
+// TestManager_AbsoluteTimeout 超过绝对超时后创建新的 session
+func TestManager_AbsoluteTimeout(t *testing.T) {
+	opts := DefaultOptions()
+	opts.AbsoluteTimeout = 60
+	m := NewManagerWithOptions(NewMemoryStore(), opts)
+
+	sess, _ := m.Load(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	sess.Set(createdKey, time.Now().Unix()-120)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: opts.CookieName, Value: sess.ID()})
+	if got := m.Start(httptest.NewRecorder(), req); got == sess.ID() {
+		t.Fatal("expired session was reused")
+	}
+}
+
+// TestManager_RefreshCookie 访问已有的 session 时重新写入 cookie，空闲超时从最后一次访问开始计算
+func TestManager_RefreshCookie(t *testing.T) {
+	opts := DefaultOptions()
+	opts.IdleTimeout = 600
+	opts.AbsoluteTimeout = 3600
+	m := NewManagerWithOptions(NewMemoryStore(), opts)
+	sess, _ := m.Load(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	tests := []struct {
+		created int64
+		maxAge  int
+	}{
+		{time.Now().Unix(), 600},
+		// 绝对超时只剩60秒
+		{time.Now().Unix() - 3540, 60},
+	}
+	for _, tt := range tests {
+		sess.Set(createdKey, tt.created)
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: opts.CookieName, Value: sess.ID()})
+		w := httptest.NewRecorder()
+		if _, err := m.Load(w, req); err != nil {
+			t.Fatal(err)
+		}
+		ck := w.Result().Cookies()
+		if len(ck) != 1 || ck[0].Value != sess.ID() || ck[0].MaxAge < tt.maxAge-1 || ck[0].MaxAge > tt.maxAge {
+			t.Errorf("created %d: unexpected cookie %v, want MaxAge %d", tt.created, ck, tt.maxAge)
+		}
+	}
+}
+
+// TestSession_ConcurrentKeys 同一个 session 的两个请求分别修改不同的 key，不会互相覆盖
+func TestSession_ConcurrentKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gow-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileStore, _ := NewFileStore(dir)
+
+	for _, store := range []Store{NewMemoryStore(), fileStore} {
+		m := NewManager("gow_session_id", 3600, store)
+		id := m.Start(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		load := func() *Session {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.AddCookie(&http.Cookie{Name: "gow_session_id", Value: id})
+			sess, err := m.Load(httptest.NewRecorder(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sess
+		}
+		// 两个请求都在对方写入之前读取了 session
+		a, b := load(), load()
+		if err := a.Set("flash", "saved"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Set("cart", 3); err != nil {
+			t.Fatal(err)
+		}
+
+		sess := load()
+		if v, _ := sess.Get("flash"); v != "saved" {
+			t.Errorf("%T: flash = %v, want saved", store, v)
+		}
+		if v, _ := sess.Get("cart"); v != 3 {
+			t.Errorf("%T: cart = %v, want 3", store, v)
+		}
+	}
+}
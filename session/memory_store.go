@@ -0,0 +1,165 @@
+package session
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memorySession 内存中的 session
+type memorySession struct {
+	lastTimeAccessed time.Time
+	values           map[interface{}]interface{}
+	info             *Info
+}
+
+// MemoryStore 使用进程内存存储 session
+//	重启后 session 丢失，也不能在多个实例间共享
+//	实现了 Indexer，可以按 userID 查询和注销 session
+type MemoryStore struct {
+	mu      sync.RWMutex
+	session map[string]*memorySession
+	users   map[string]map[string]*memorySession
+}
+
+// NewMemoryStore return a memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		session: make(map[string]*memorySession),
+		users:   make(map[string]map[string]*memorySession),
+	}
+}
+
+// Read Read
+func (m *MemoryStore) Read(r *http.Request, sessionID string, maxLifeTime int64) (map[interface{}]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.session[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	if session.lastTimeAccessed.Unix()+maxLifeTime < time.Now().Unix() {
+		m.remove(sessionID)
+		return nil, nil
+	}
+	session.lastTimeAccessed = time.Now()
+	return copyValues(session.values), nil
+}
+
+// Write Write
+func (m *MemoryStore) Write(w http.ResponseWriter, sessionID string, values map[interface{}]interface{}, maxLifeTime int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if session, ok := m.session[sessionID]; ok {
+		session.lastTimeAccessed = time.Now()
+		session.values = copyValues(values)
+		return nil
+	}
+	m.session[sessionID] = &memorySession{
+		lastTimeAccessed: time.Now(),
+		values:           copyValues(values),
+	}
+	return nil
+}
+
+// Update 只修改 set 和 del 中的 key，session 不存在或已过期时不修改
+func (m *MemoryStore) Update(w http.ResponseWriter, sessionID string, set map[interface{}]interface{}, del []interface{}, maxLifeTime int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.session[sessionID]
+	if !ok {
+		return nil
+	}
+	if session.lastTimeAccessed.Unix()+maxLifeTime < time.Now().Unix() {
+		m.remove(sessionID)
+		return nil
+	}
+	session.lastTimeAccessed = time.Now()
+	applyValues(session.values, set, del)
+	return nil
+}
+
+// Destroy Destroy
+func (m *MemoryStore) Destroy(w http.ResponseWriter, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(sessionID)
+	return nil
+}
+
+// GC GC
+func (m *MemoryStore) GC(maxLifeTime int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for sessionID, session := range m.session {
+		if session.lastTimeAccessed.Unix()+maxLifeTime < time.Now().Unix() {
+			m.remove(sessionID)
+		}
+	}
+}
+
+// Bind Bind
+func (m *MemoryStore) Bind(info *Info) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.session[info.SessionID]
+	if !ok {
+		return nil
+	}
+	if session.info != nil && session.info.UserID != info.UserID {
+		delete(m.users[session.info.UserID], info.SessionID)
+	}
+	cp := *info
+	session.info = &cp
+	if m.users[info.UserID] == nil {
+		m.users[info.UserID] = make(map[string]*memorySession)
+	}
+	m.users[info.UserID][info.SessionID] = session
+	return nil
+}
+
+// Touch Touch
+func (m *MemoryStore) Touch(userID, sessionID string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if session, ok := m.users[userID][sessionID]; ok {
+		session.info.LastAccess = t
+	}
+	return nil
+}
+
+// Index 按最后访问时间倒序返回
+//	包括已过期但还没有被 GC 删除的 session，由 Manager.UserSessions 过滤
+func (m *MemoryStore) Index(userID string) ([]*Info, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]*Info, 0, len(m.users[userID]))
+	for _, session := range m.users[userID] {
+		cp := *session.info
+		// Read Write 也会更新访问时间，使用较晚的一个
+		if session.lastTimeAccessed.After(cp.LastAccess) {
+			cp.LastAccess = session.lastTimeAccessed
+		}
+		list = append(list, &cp)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastAccess.After(list[j].LastAccess)
+	})
+	return list, nil
+}
+
+// remove 删除 session 和索引，需要持有锁
+func (m *MemoryStore) remove(sessionID string) {
+	session, ok := m.session[sessionID]
+	if !ok {
+		return
+	}
+	if session.info != nil {
+		delete(m.users[session.info.UserID], sessionID)
+		if len(m.users[session.info.UserID]) == 0 {
+			delete(m.users, session.info.UserID)
+		}
+	}
+	delete(m.session, sessionID)
+}
@@ -0,0 +1,101 @@
+package session
+
+import (
+	"encoding/gob"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCookieName  = "gow_session_id"
+	defaultIdleTimeout = 3600
+)
+
+// metaKey session 内部使用的 key，不会与业务的 key 冲突
+type metaKey string
+
+const (
+	// createdKey session 的创建时间
+	createdKey metaKey = "created"
+)
+
+func init() {
+	gob.Register(metaKey(""))
+}
+
+// Options session 选项
+type Options struct {
+	CookieName string        //存储 sessionID 的 cookie 名称，默认为 gow_session_id
+	Path       string        //默认为 /
+	Domain     string        //
+	Secure     bool          //只在 HTTPS 下发送
+	HttpOnly   bool          //禁止 js 读取
+	SameSite   http.SameSite //
+
+	// IdleTimeout 空闲超时，单位为秒，默认为3600
+	//	超过此时间没有访问时，session 失效
+	IdleTimeout int64
+
+	// AbsoluteTimeout 绝对超时，单位为秒，为0时不限制
+	//	从创建开始超过此时间后，无论是否访问，session 都会失效
+	AbsoluteTimeout int64
+}
+
+// DefaultOptions 默认选项
+func DefaultOptions() Options {
+	return Options{
+		CookieName:  defaultCookieName,
+		Path:        "/",
+		HttpOnly:    true,
+		IdleTimeout: defaultIdleTimeout,
+	}
+}
+
+// ParseSameSite 把 lax strict none 转换为 http.SameSite
+//	其他值返回 http.SameSiteDefaultMode
+func ParseSameSite(s string) http.SameSite {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+// prepare 设置默认值
+func (o Options) prepare() Options {
+	if o.CookieName == "" {
+		o.CookieName = defaultCookieName
+	}
+	if o.Path == "" {
+		o.Path = "/"
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = defaultIdleTimeout
+	}
+	return o
+}
+
+// cookie 返回使用选项属性的 cookie
+//	maxAge 小于0时，删除 cookie
+func (o Options) cookie(name, value string, maxAge int) *http.Cookie {
+	ck := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		Secure:   o.Secure,
+		HttpOnly: o.HttpOnly,
+		SameSite: o.SameSite,
+		MaxAge:   maxAge,
+	}
+	if maxAge < 0 {
+		ck.Expires = time.Unix(0, 0)
+	}
+	return ck
+}
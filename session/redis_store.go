@@ -0,0 +1,116 @@
+package session
+
+import (
+	rds "github.com/gkzy/gow/lib/redis"
+	"net/http"
+)
+
+const (
+	defaultRedisPrefix = "session:"
+)
+
+// RedisStore 使用 redis 的 hash 存储 session，可在多个实例间共享
+//	使用 NewRedisStore 时需要先调用 redis.InitRDSClient 初始化连接
+//	过期由 redis 的 TTL 处理
+type RedisStore struct {
+	prefix string
+	rds    *rds.RDSCommon
+}
+
+// NewRedisStore return a redis store
+//	prefix 为 key 的前缀，默认为 session:
+//	使用 redis.InitRDSClient 初始化的共用连接
+func NewRedisStore(prefix string) *RedisStore {
+	return NewRedisStoreWithClient(prefix, rds.GetRDSCommon())
+}
+
+// NewRedisStoreWithClient 使用指定的连接，如 redis.NewRDSCommon 创建的独立连接池
+//		rc, err := redis.NewRDSCommon(&redis.RDSConfig{Host: "127.0.0.1", Port: 6379, DB: 1})
+//		store := session.NewRedisStoreWithClient("session:", rc)
+func NewRedisStoreWithClient(prefix string, client *rds.RDSCommon) *RedisStore {
+	if prefix == "" {
+		prefix = defaultRedisPrefix
+	}
+	return &RedisStore{
+		prefix: prefix,
+		rds:    client,
+	}
+}
+
+// Read Read
+func (m *RedisStore) Read(r *http.Request, sessionID string, maxLifeTime int64) (map[interface{}]interface{}, error) {
+	key := m.prefix + sessionID
+	fields, err := m.rds.GetHashStringMap(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if _, err = m.rds.SetEXPIRE(key, maxLifeTime); err != nil {
+		return nil, err
+	}
+	values := make(map[interface{}]interface{}, len(fields))
+	for field, v := range fields {
+		k, err := decodeValue([]byte(field))
+		if err != nil {
+			return nil, err
+		}
+		if values[k], err = decodeValue([]byte(v)); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Write 替换 sessionID 的所有值
+func (m *RedisStore) Write(w http.ResponseWriter, sessionID string, values map[interface{}]interface{}, maxLifeTime int64) error {
+	fields, err := encodeFields(values)
+	if err != nil {
+		return err
+	}
+	return m.rds.SetHashEx(m.prefix+sessionID, fields, maxLifeTime)
+}
+
+// Update 使用 lua 脚本在 session 存在时 HDEL HSET，只修改 set 和 del 中的 key
+//	每个 key 为 hash 中的一个 field，key 和值都使用 gob 编码
+func (m *RedisStore) Update(w http.ResponseWriter, sessionID string, set map[interface{}]interface{}, del []interface{}, maxLifeTime int64) error {
+	fields, err := encodeFields(set)
+	if err != nil {
+		return err
+	}
+	dels := make([]string, 0, len(del))
+	for _, k := range del {
+		field, err := encodeValue(k)
+		if err != nil {
+			return err
+		}
+		dels = append(dels, string(field))
+	}
+	_, err = m.rds.UpdateHashEx(m.prefix+sessionID, fields, dels, maxLifeTime)
+	return err
+}
+
+// encodeFields 把 values 编码为 hash 的 field 和值
+func encodeFields(values map[interface{}]interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		field, err := encodeValue(k)
+		if err != nil {
+			return nil, err
+		}
+		if fields[string(field)], err = encodeValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// Destroy Destroy
+func (m *RedisStore) Destroy(w http.ResponseWriter, sessionID string) error {
+	_, err := m.rds.DEL(m.prefix + sessionID)
+	return err
+}
+
+// GC 由 redis 的 TTL 处理
+func (m *RedisStore) GC(maxLifeTime int64) {}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrNoWriter 使用 cookie 存储时，需要 http.ResponseWriter
+	ErrNoWriter = errors.New("session: the store needs http.ResponseWriter")
+	// ErrNoRequest 使用 cookie 存储时，需要 *http.Request
+	ErrNoRequest = errors.New("session: the store needs *http.Request")
+)
+
+// Store session 存储
+//	内置: MemoryStore RedisStore FileStore CookieStore
+//	存储自定义类型的值时，需要先调用 gob.Register 注册类型
+type Store interface {
+	// Read 读取 sessionID 的值，并更新最后访问时间
+	//	不存在或已过期时返回nil
+	Read(r *http.Request, sessionID string, maxLifeTime int64) (map[interface{}]interface{}, error)
+
+	// Write 保存 sessionID 的值
+	Write(w http.ResponseWriter, sessionID string, values map[interface{}]interface{}, maxLifeTime int64) error
+
+	// Destroy 删除 sessionID
+	Destroy(w http.ResponseWriter, sessionID string) error
+
+	// GC 删除过期的 session
+	GC(maxLifeTime int64)
+}
+
+// Updater 只保存修改的值的 Store
+//	Session.Set Delete 时只写入修改的 key，同一个 session 的并发请求修改不同的 key 时不会互相覆盖
+//	没有实现时写入全部的值，并发请求中后写入的会覆盖先写入的
+//	sessionID 不存在(已注销或过期)时不能写入，否则并发的请求会恢复已注销的 session
+//	MemoryStore RedisStore FileStore 已实现；CookieStore 的值保存在客户端，无法实现
+type Updater interface {
+	// Update 设置 set 中的值，删除 del 中的 key，sessionID 不存在时不修改
+	Update(w http.ResponseWriter, sessionID string, set map[interface{}]interface{}, del []interface{}, maxLifeTime int64) error
+}
+
+// optionsSetter 使用 Manager Options 的 Store，如 CookieStore 使用其中的 cookie 属性
+//	NewManagerWithOptions 时设置
+type optionsSetter interface {
+	setOptions(opts Options)
+}
+
+// applyValues 把 set 和 del 应用到 values
+func applyValues(values, set map[interface{}]interface{}, del []interface{}) {
+	for _, k := range del {
+		delete(values, k)
+	}
+	for k, v := range set {
+		values[k] = v
+	}
+}
+
+// encodeValue gob encode 单个 key 或值
+func encodeValue(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeValue gob decode 单个 key 或值
+func decodeValue(b []byte) (interface{}, error) {
+	var v interface{}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// encodeValues gob encode
+func encodeValues(values map[interface{}]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeValues gob decode
+func decodeValues(b []byte) (map[interface{}]interface{}, error) {
+	values := make(map[interface{}]interface{})
+	if len(b) == 0 {
+		return values, nil
+	}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// copyValues 复制 values
+func copyValues(values map[interface{}]interface{}) map[interface{}]interface{} {
+	ret := make(map[interface{}]interface{}, len(values))
+	for k, v := range values {
+		ret[k] = v
+	}
+	return ret
+}
@@ -0,0 +1,162 @@
+package session
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestFileStore 重新创建 store 后 session 仍然存在
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gow-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, _ := NewFileStore(dir)
+	m := NewManager("gow_session_id", 3600, store)
+	id := m.Start(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	m.Set(id, "uid", int64(10))
+
+	store, _ = NewFileStore(dir)
+	m = NewManager("gow_session_id", 3600, store)
+	if v, _ := m.Get(id, "uid"); v != int64(10) {
+		t.Fatalf("got uid %v", v)
+	}
+	if _, err = store.Read(nil, "../"+id, 3600); err == nil {
+		t.Fatal("path traversal session id was accepted")
+	}
+}
+
+// TestCookieStore 数据保存在 cookie 中，篡改后失效
+func TestCookieStore(t *testing.T) {
+	for _, blockKey := range []string{"", "0123456789abcdef"} {
+		store, err := NewCookieStore("", "hash-key", blockKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := NewManager("gow_session_id", 3600, store)
+
+		w := httptest.NewRecorder()
+		sess, err := m.Load(w, httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = sess.Set("name", "gow"); err != nil {
+			t.Fatal(err)
+		}
+
+		// 同名 cookie 以最后一个为准
+		last := make(map[string]*http.Cookie)
+		for _, ck := range w.Result().Cookies() {
+			last[ck.Name] = ck
+		}
+		cookies := make([]*http.Cookie, 0, len(last))
+		for _, ck := range last {
+			cookies = append(cookies, ck)
+		}
+		req := httptest.NewRequest("GET", "/", nil)
+		for _, ck := range cookies {
+			req.AddCookie(ck)
+		}
+		sess, err = m.Load(httptest.NewRecorder(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := sess.Get("name"); v != "gow" {
+			t.Fatalf("block key %q: got name %v", blockKey, v)
+		}
+
+		// 篡改数据
+		req = httptest.NewRequest("GET", "/", nil)
+		for _, ck := range cookies {
+			if ck.Name == defaultCookieStoreName {
+				ck = &http.Cookie{Name: ck.Name, Value: "x" + ck.Value}
+			}
+			req.AddCookie(ck)
+		}
+		sess, _ = m.Load(httptest.NewRecorder(), req)
+		if _, ok := sess.Get("name"); ok {
+			t.Fatalf("block key %q: tampered cookie was accepted", blockKey)
+		}
+	}
+}
+
+// TestCookieStore_Options 数据 cookie 使用 Manager 的 cookie 属性
+func TestCookieStore_Options(t *testing.T) {
+	store, _ := NewCookieStore("", "hash-key", "")
+	opts := DefaultOptions()
+	opts.Secure = true
+	opts.SameSite = http.SameSiteStrictMode
+	m := NewManagerWithOptions(store, opts)
+
+	w := httptest.NewRecorder()
+	sess, _ := m.Load(w, httptest.NewRequest("GET", "/", nil))
+	sess.Set("name", "gow")
+	found := false
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name != defaultCookieStoreName {
+			continue
+		}
+		found = true
+		if !ck.Secure || ck.SameSite != http.SameSiteStrictMode {
+			t.Fatalf("cookie store ignored the manager options: %v", ck)
+		}
+	}
+	if !found {
+		t.Fatal("cookie store did not write the data cookie")
+	}
+}
+
+// TestEncodeValue RedisStore 使用编码后的 key 作为 hash 的 field，相同的 key 编码结果必须相同
+func TestEncodeValue(t *testing.T) {
+	for _, k := range []interface{}{"uid", int64(10), createdKey} {
+		a, err := encodeValue(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := encodeValue(k)
+		if string(a) != string(b) {
+			t.Errorf("encodeValue(%v) is not stable", k)
+		}
+		v, err := decodeValue(a)
+		if err != nil || v != k {
+			t.Errorf("decodeValue(encodeValue(%v)) = %v, %v", k, v, err)
+		}
+	}
+}
+
+// TestUpdater_Destroyed 已删除的 session 不会被 Update 恢复
+func TestUpdater_Destroyed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gow-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileStore, _ := NewFileStore(dir)
+
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "file": fileStore} {
+		values := map[interface{}]interface{}{"a": 1}
+		if err = store.Write(nil, "sid", values, 3600); err != nil {
+			t.Fatal(err)
+		}
+		updater := store.(Updater)
+		if err = updater.Update(nil, "sid", map[interface{}]interface{}{"b": 2}, nil, 3600); err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := store.Read(nil, "sid", 3600); v["a"] != 1 || v["b"] != 2 {
+			t.Errorf("%s: after Update got %v", name, v)
+		}
+
+		store.Destroy(nil, "sid")
+		if err = updater.Update(nil, "sid", map[interface{}]interface{}{"c": 3}, nil, 3600); err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := store.Read(nil, "sid", 3600); v != nil {
+			t.Errorf("%s: destroyed session was recreated by Update: %v", name, v)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gow
+
+import (
+	"github.com/gkzy/gow/render"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Stream 循环调用 step 写入响应，每次调用后 flush
+//	step 返回 false 时结束；客户端断开时结束并返回 true
+//		c.Stream(func(w io.Writer) bool {
+//			msg, ok := <-ch
+//			if !ok {
+//				return false
+//			}
+//			w.Write(msg)
+//			return true
+//		})
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+		}
+		keepOpen := step(c.Writer)
+		c.Writer.Flush()
+		if !keepOpen {
+			return false
+		}
+	}
+}
+
+// SSEvent 推送一个 Server-Sent Event
+//		c.SSEvent("status", gow.H{"order_id": 1, "status": "paid"})
+func (c *Context) SSEvent(event string, data interface{}) error {
+	return c.SSEventWithID("", event, data)
+}
+
+// SSEventWithID 推送一个带 id 的 Server-Sent Event
+//	客户端重连时，在 Last-Event-ID 中带上最后收到的 id，见 LastEventID
+func (c *Context) SSEventWithID(id, event string, data interface{}) error {
+	return c.writeSSE(render.SSEvent{ID: id, Event: event, Data: data})
+}
+
+// SSEHeartbeat 发送注释行，防止代理和负载均衡断开空闲的连接
+func (c *Context) SSEHeartbeat() error {
+	c.sseHeader()
+	if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// SSEStream 从 events 读取事件并推送，直到 events 关闭或客户端断开
+//	heartbeat 大于0时，空闲 heartbeat 后发送一次心跳
+//	客户端断开时返回 true，调用方可以在返回后取消订阅
+//	设置了 engine.WriteTimeout 时会中断长连接，客户端会使用 Last-Event-ID 重连
+//		ch := hub.Subscribe(orderID)
+//		defer hub.Unsubscribe(orderID, ch)
+//		c.SSEStream(ch, 15*time.Second)
+func (c *Context) SSEStream(events <-chan render.SSEvent, heartbeat time.Duration) bool {
+	c.sseHeader()
+	c.Writer.Flush()
+
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	done := c.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		case ev, ok := <-events:
+			if !ok {
+				return false
+			}
+			if err := c.writeSSE(ev); err != nil {
+				return true
+			}
+		case <-tick:
+			if err := c.SSEHeartbeat(); err != nil {
+				return true
+			}
+		}
+	}
+}
+
+// LastEventID 客户端重连时最后收到的事件 id
+//	EventSource 使用 Last-Event-ID 请求头，polyfill 通常使用 query 中的 lastEventId
+func (c *Context) LastEventID() string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("lastEventId")
+}
+
+// writeSSE 写入事件并 flush
+func (c *Context) writeSSE(ev render.SSEvent) error {
+	c.sseHeader()
+	if err := ev.Render(c.Writer); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// sseHeader 第一次写入前设置 SSE 的响应头
+func (c *Context) sseHeader() {
+	if c.Writer.Written() {
+		return
+	}
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// nginx 不缓冲响应
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+}
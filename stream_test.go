@@ -0,0 +1,121 @@
+package gow
+
+import (
+	"context"
+	"github.com/gkzy/gow/render"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEvent_Render(t *testing.T) {
+	tests := []struct {
+		ev   render.SSEvent
+		want string
+	}{
+		{render.SSEvent{Event: "status", Data: "paid"}, "event:status\ndata:paid\n\n"},
+		{render.SSEvent{ID: "1", Data: H{"a": 1}}, "id:1\ndata:{\"a\":1}\n\n"},
+		// 多行 data 拆分为多个 data 行
+		{render.SSEvent{Data: "a\r\nb\nc"}, "data:a\ndata:b\ndata:c\n\n"},
+		// id 和 event 中的换行被去掉，不能伪造字段
+		{render.SSEvent{ID: "1\nevent:x", Event: "a\r\nb", Data: []byte("x")}, "id:1event:x\nevent:ab\ndata:x\n\n"},
+		{render.SSEvent{Retry: 3000, Data: ""}, "retry:3000\ndata:\n\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := tt.ev.Render(w); err != nil {
+			t.Fatal(err)
+		}
+		if w.Body.String() != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.ev, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestSSEvent(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.SSEvent("status", "paid")
+		c.SSEventWithID("2", "status", "shipped")
+	})
+	w := performRequest(r, "GET", "/", nil, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" ||
+		w.Header().Get("Cache-Control") != "no-cache" || w.Header().Get("X-Accel-Buffering") != "no" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+	if want := "event:status\ndata:paid\n\nid:2\nevent:status\ndata:shipped\n\n"; w.Body.String() != want {
+		t.Errorf("got %q, want %q", w.Body.String(), want)
+	}
+	if !w.Flushed {
+		t.Error("events were not flushed")
+	}
+}
+
+func TestSSEStream(t *testing.T) {
+	events := make(chan render.SSEvent)
+	var disconnected bool
+	r := New()
+	r.GET("/", func(c *Context) {
+		disconnected = c.SSEStream(events, 10*time.Millisecond)
+	})
+	go func() {
+		events <- render.SSEvent{ID: "1", Data: "a"}
+		// 空闲时发送心跳
+		time.Sleep(50 * time.Millisecond)
+		events <- render.SSEvent{ID: "2", Data: "b"}
+		close(events)
+	}()
+	w := performRequest(r, "GET", "/", nil, nil)
+	body := w.Body.String()
+	if disconnected || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("disconnected = %v, header %v", disconnected, w.Header())
+	}
+	first, heartbeat, second := strings.Index(body, "id:1\ndata:a\n\n"), strings.Index(body, ": heartbeat\n\n"), strings.Index(body, "id:2\ndata:b\n\n")
+	if first < 0 || heartbeat < first || second < heartbeat {
+		t.Errorf("unexpected stream %q", body)
+	}
+
+	// 客户端断开时返回 true
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	events = make(chan render.SSEvent)
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SSEStream did not return after the client disconnected")
+	}
+	if !disconnected {
+		t.Error("SSEStream should return true when the client disconnects")
+	}
+}
+
+func TestLastEventID(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.String(c.LastEventID())
+	})
+	tests := []struct {
+		path    string
+		headers map[string]string
+		want    string
+	}{
+		{"/", map[string]string{"Last-Event-ID": "42"}, "42"},
+		{"/?lastEventId=7", nil, "7"},
+		// 请求头优先
+		{"/?lastEventId=7", map[string]string{"Last-Event-ID": "42"}, "42"},
+		{"/", nil, ""},
+	}
+	for _, tt := range tests {
+		if w := performRequest(r, "GET", tt.path, nil, tt.headers); w.Body.String() != tt.want {
+			t.Errorf("%s %v: got %q, want %q", tt.path, tt.headers, w.Body.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package gow
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrHijackTimeout Timeout 中不支持 Hijack
+var ErrHijackTimeout = errors.New("gow: Hijack is not supported in Timeout")
+
+// Timeout 超时中间件，可用于全局、分组或单个路由
+//	超时后取消 c 的 context(c.Done() 会 close)，并返回 statusCode 的错误响应(见 AbortWithError)，默认为 503
+//	statusCode 已注册为错误码时使用注册的消息，如 ErrServiceUnavailable ErrGatewayTimeout
+//	handler 的响应先写入缓冲区，正常结束时再写入客户端，超时后的写入会被丢弃
+//	返回响应后仍会等待 handler 结束，handler 需要检查 c.Done() 或把 c 传给支持 context 的方法
+//		api := r.Group("/api", gow.Timeout(3*time.Second))
+//		r.GET("/report", gow.Timeout(30*time.Second, http.StatusGatewayTimeout), report)
+func Timeout(timeout time.Duration, statusCode ...int) HandlerFunc {
+	code := http.StatusServiceUnavailable
+	if len(statusCode) > 0 {
+		code = statusCode[0]
+	}
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Req.Context(), timeout)
+		defer cancel()
+
+		// 超时响应与 handler 使用同一个 request id
+		c.RequestID()
+		// handler 仍在使用 c，超时响应使用单独的 Context
+		ec := c.timeoutContext()
+		req, w := c.Req, c.Writer
+		tw := &timeoutWriter{
+			ResponseWriter: w,
+			header:         make(http.Header),
+			status:         defaultStatus,
+			size:           noWritten,
+		}
+		c.Req = req.WithContext(ctx)
+		c.Writer = tw
+
+		done := make(chan struct{})
+		var panicked interface{}
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked = trace(fmt.Sprintf("%s", p))
+				}
+				close(done)
+			}()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+			if ctx.Err() == context.DeadlineExceeded {
+				ec.AbortWithError(timeoutError(code).Wrap(ctx.Err()))
+				w.Flush()
+			}
+			// 等待 handler 结束，避免 Context 被复用时仍在使用
+			<-done
+		}
+
+		c.Req, c.Writer = req, w
+		if panicked != nil {
+			panic(panicked)
+		}
+		if tw.timedOut {
+			c.Errors = append(c.Errors, ec.Errors...)
+			c.StatusCode = code
+			return
+		}
+		for k, vs := range tw.header {
+			w.Header()[k] = vs
+		}
+		// 只设置了状态码没有写入 body 时也要写入，如 204 304 和重定向
+		if tw.status != defaultStatus || tw.Written() {
+			w.WriteHeader(tw.status)
+			w.WriteHeaderNow()
+			w.Write(tw.buf.Bytes())
+		}
+	}
+}
+
+// timeoutError 超时的错误，statusCode 没有注册时使用 http 状态码的文本作为消息
+func timeoutError(statusCode int) *BizError {
+	if be, ok := LookupError(statusCode); ok && be.Status == statusCode {
+		return be
+	}
+	return &BizError{Code: statusCode, Status: statusCode}
+}
+
+// timeoutContext 返回写入超时响应的 Context，与 c 使用同一个请求和 request id
+func (c *Context) timeoutContext() *Context {
+	return &Context{
+		Writer:    c.Writer,
+		Req:       c.Req,
+		Keys:      make(map[string]interface{}),
+		Data:      make(map[interface{}]interface{}),
+		index:     -1,
+		engine:    c.engine,
+		requestID: c.requestID,
+		lang:      c.lang,
+	}
+}
+
+// timeoutWriter 缓存 handler 的响应
+type timeoutWriter struct {
+	ResponseWriter
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	size     int
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if code > 0 && !w.timedOut {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.size == noWritten {
+		w.size = 0
+	}
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.size == noWritten {
+		w.size = 0
+	}
+	n, err := w.buf.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
+
+func (w *timeoutWriter) Written() bool {
+	return w.Size() != noWritten
+}
+
+// Flush 响应在 handler 结束后才写入客户端
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, ErrHijackTimeout
+}
@@ -0,0 +1,88 @@
+package gow
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	r := New()
+	r.Use(Recovery())
+	late := make(chan error, 1)
+	r.GET("/ok", Timeout(time.Second), func(c *Context) {
+		c.SetHeader("X-Test", "1")
+		c.String("ok")
+	})
+	r.GET("/no-content", Timeout(time.Second), func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/redirect", Timeout(time.Second), func(c *Context) {
+		c.Redirect(http.StatusFound, "/ok")
+	})
+	slow := func(c *Context) {
+		<-c.Done()
+		// 等待超时响应写入后再写
+		time.Sleep(20 * time.Millisecond)
+		_, err := c.Writer.Write([]byte("late"))
+		late <- err
+	}
+	r.GET("/slow", Timeout(20*time.Millisecond), slow)
+	r.GET("/gateway", Timeout(20*time.Millisecond, http.StatusGatewayTimeout), slow)
+	r.GET("/panic", Timeout(time.Second), func(c *Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		method  string
+		path    string
+		headers map[string]string
+		code    int
+		body    string
+		header  string
+		value   string
+	}{
+		{"GET", "/ok", nil, http.StatusOK, "ok", "X-Test", "1"},
+		{"GET", "/no-content", nil, http.StatusNoContent, "", "", ""},
+		{"POST", "/redirect", nil, http.StatusFound, "", "Location", "/ok"},
+		{"GET", "/slow", map[string]string{"Accept-Language": "en"}, http.StatusServiceUnavailable, `"msg": "service unavailable`, "Content-Type", "application/json; charset=utf-8"},
+		{"GET", "/gateway", map[string]string{"Accept-Language": "zh-CN"}, http.StatusGatewayTimeout, `"msg": "请求超时`, "", ""},
+		{"GET", "/gateway", map[string]string{"Accept": "text/html"}, http.StatusGatewayTimeout, "<h1>504</h1>", "Content-Type", "text/html; charset=utf-8"},
+		{"GET", "/panic", nil, http.StatusInternalServerError, `"code": 500`, "", ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, tt.method, tt.path, nil, tt.headers)
+		if w.Code != tt.code {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.code)
+		}
+		if !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s %s: body = %q, want %q", tt.method, tt.path, w.Body.String(), tt.body)
+		}
+		if tt.header != "" && w.Header().Get(tt.header) != tt.value {
+			t.Errorf("%s %s: %s = %q, want %q", tt.method, tt.path, tt.header, w.Header().Get(tt.header), tt.value)
+		}
+		if tt.path == "/slow" || tt.path == "/gateway" {
+			if err := <-late; err != http.ErrHandlerTimeout {
+				t.Errorf("%s: late write err = %v, want ErrHandlerTimeout", tt.path, err)
+			}
+		}
+	}
+}
+
+func TestTimeout_RequestID(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/", Timeout(20*time.Millisecond), func(c *Context) {
+		<-c.Done()
+	})
+	w := performRequest(r, "GET", "/", nil, map[string]string{HeaderRequestID: "req-1"})
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != http.StatusServiceUnavailable || resp.RequestID != "req-1" || w.Header().Get(HeaderRequestID) != "req-1" {
+		t.Errorf("unexpected response %+v, header %q", resp, w.Header().Get(HeaderRequestID))
+	}
+}
@@ -0,0 +1,220 @@
+package gow
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCertCheckInterval 检查证书文件是否变化的间隔
+	defaultCertCheckInterval = 10 * time.Second
+)
+
+// CertReloader 证书加载器
+//	证书文件变化后，在下一次 TLS 握手时自动重新加载
+type CertReloader struct {
+	certFile  string
+	keyFile   string
+	reloadMu  sync.Mutex // 检查和重新加载只在一个 goroutine 中进行
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewCertReloader 加载证书并返回 CertReloader
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	m := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload 重新加载证书
+//	加载失败时，继续使用原来的证书
+func (m *CertReloader) Reload() error {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	return m.reload()
+}
+
+// reload 重新加载证书，需要持有 reloadMu
+func (m *CertReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(m.certFile, m.keyFile)
+	if err != nil {
+		return err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return err
+		}
+	}
+	modTime := m.fileModTime()
+
+	m.mu.Lock()
+	m.cert = &cert
+	m.modTime = modTime
+	m.lastCheck = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// Certificate 返回当前证书
+//	距离上次检查超过10秒时，检查证书文件是否变化，同时到达的握手只有一个会检查和重新加载
+func (m *CertReloader) Certificate() *tls.Certificate {
+	m.mu.RLock()
+	cert, lastCheck := m.cert, m.lastCheck
+	m.mu.RUnlock()
+	if time.Since(lastCheck) < defaultCertCheckInterval {
+		return cert
+	}
+
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	m.mu.Lock()
+	// 等待锁时，其他握手已经检查过
+	if time.Since(m.lastCheck) < defaultCertCheckInterval {
+		m.mu.Unlock()
+		return m.current()
+	}
+	m.lastCheck = time.Now()
+	modTime := m.modTime
+	m.mu.Unlock()
+
+	if t := m.fileModTime(); !t.IsZero() && !t.Equal(modTime) {
+		if err := m.reload(); err != nil {
+			debugPrintError(fmt.Errorf("reload certificate %s: %v, keep using the old certificate", m.certFile, err))
+		} else {
+			debugPrint("reload certificate: %s", m.certFile)
+		}
+	}
+	return m.current()
+}
+
+// current 返回当前证书
+func (m *CertReloader) current() *tls.Certificate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cert
+}
+
+// GetCertificate 实现 tls.Config.GetCertificate
+func (m *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return m.Certificate(), nil
+}
+
+// fileModTime 返回证书和私钥文件中最新的修改时间
+func (m *CertReloader) fileModTime() (t time.Time) {
+	for _, file := range []string{m.certFile, m.keyFile} {
+		fi, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return
+}
+
+// AddCertificate 添加证书
+//	可多次调用，TLS 握手时按 SNI 选择证书，没有匹配时使用第一个
+//	证书文件变化后自动重新加载
+//		r.AddCertificate("a.com.pem", "a.com.key")
+//		r.AddCertificate("b.com.pem", "b.com.key")
+//		r.RunTLS("", "", ":443")
+func (engine *Engine) AddCertificate(certFile, keyFile string) error {
+	cr, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	engine.mu.Lock()
+	engine.certs = append(engine.certs, cr)
+	engine.mu.Unlock()
+	return nil
+}
+
+// ReloadCertificates 立即重新加载所有证书
+func (engine *Engine) ReloadCertificates() (err error) {
+	engine.mu.Lock()
+	certs := engine.certs
+	engine.mu.Unlock()
+	for _, cr := range certs {
+		if e := cr.Reload(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// SetClientCA 开启客户端证书校验(mTLS)
+//	caFile 为 PEM 格式的 CA 证书，可包含多个
+//	required 为 true 时，拒绝没有证书的客户端；为 false 时，只校验客户端提供的证书
+//	校验通过的证书可通过 c.ClientCertificate() 获取
+func (engine *Engine) SetClientCA(caFile string, required bool) error {
+	b, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return fmt.Errorf("no certificate found in %s", caFile)
+	}
+	engine.clientCAs = pool
+	engine.clientAuth = tls.VerifyClientCertIfGiven
+	if required {
+		engine.clientAuth = tls.RequireAndVerifyClientCert
+	}
+	return nil
+}
+
+//================================private func=============================
+
+// tlsConfig 返回 RunTLS 使用的 tls.Config
+//	certFile 和 keyFile 不为空时，先添加到证书列表
+func (engine *Engine) tlsConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile != "" || keyFile != "" {
+		if err := engine.AddCertificate(certFile, keyFile); err != nil {
+			return nil, err
+		}
+	}
+	engine.mu.Lock()
+	n := len(engine.certs)
+	engine.mu.Unlock()
+	if n == 0 {
+		return nil, errors.New("no certificate, please call AddCertificate")
+	}
+	return &tls.Config{
+		GetCertificate: engine.getCertificate,
+		ClientCAs:      engine.clientCAs,
+		ClientAuth:     engine.clientAuth,
+	}, nil
+}
+
+// getCertificate 按 SNI 选择证书
+func (engine *Engine) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	engine.mu.Lock()
+	certs := engine.certs
+	engine.mu.Unlock()
+
+	if len(certs) == 1 || hello.ServerName == "" {
+		return certs[0].Certificate(), nil
+	}
+	for _, cr := range certs {
+		cert := cr.Certificate()
+		if cert.Leaf != nil && cert.Leaf.VerifyHostname(hello.ServerName) == nil {
+			return cert, nil
+		}
+	}
+	return certs[0].Certificate(), nil
+}
@@ -0,0 +1,222 @@
+package gow
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testCert 测试用的证书
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCert 生成 name 的证书并写入 dir，parent 为nil时为自签名的 CA 证书
+func newTestCert(t *testing.T, dir, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	tc := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".pem"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	ioutil.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return tc
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gow-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGetCertificate_SNI(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a := newTestCert(t, dir, "a.test", nil)
+	b := newTestCert(t, dir, "b.test", nil)
+
+	r := New()
+	if err := r.AddCertificate(a.certFile, a.keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddCertificate(b.certFile, b.keyFile); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		serverName string
+		want       string
+	}{
+		{"a.test", "a.test"},
+		{"b.test", "b.test"},
+		{"B.TEST", "b.test"},
+		// 没有匹配时使用第一个
+		{"c.test", "a.test"},
+		{"", "a.test"},
+	}
+	for _, tt := range tests {
+		cert, err := r.getCertificate(&tls.ClientHelloInfo{ServerName: tt.serverName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cert.Leaf.Subject.CommonName; got != tt.want {
+			t.Errorf("SNI %q: got %s, want %s", tt.serverName, got, tt.want)
+		}
+	}
+	if err := r.AddCertificate(filepath.Join(dir, "none.pem"), filepath.Join(dir, "none.key")); err == nil {
+		t.Error("AddCertificate with missing files should return an error")
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	old := newTestCert(t, dir, "a.test", nil)
+	cr, err := NewCertReloader(old.certFile, old.keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 检查间隔内不重新加载
+	renewed := newTestCert(t, dir, "renewed.test", nil)
+	replace := func(certPEM, keyPEM []byte, mod time.Time) {
+		ioutil.WriteFile(old.certFile, certPEM, 0600)
+		ioutil.WriteFile(old.keyFile, keyPEM, 0600)
+		os.Chtimes(old.certFile, mod, mod)
+		os.Chtimes(old.keyFile, mod, mod)
+	}
+	certPEM, _ := ioutil.ReadFile(renewed.certFile)
+	keyPEM, _ := ioutil.ReadFile(renewed.keyFile)
+	replace(certPEM, keyPEM, time.Now().Add(time.Minute))
+	if got := cr.Certificate().Leaf.Subject.CommonName; got != "a.test" {
+		t.Fatalf("reloaded within the check interval: %s", got)
+	}
+
+	// 超过检查间隔后，并发的握手都得到新证书
+	cr.mu.Lock()
+	cr.lastCheck = time.Time{}
+	cr.mu.Unlock()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := cr.Certificate().Leaf.Subject.CommonName; got != "renewed.test" {
+				t.Errorf("after reload got %s, want renewed.test", got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 加载失败时继续使用原来的证书
+	replace([]byte("broken"), keyPEM, time.Now().Add(2*time.Minute))
+	cr.mu.Lock()
+	cr.lastCheck = time.Time{}
+	cr.mu.Unlock()
+	if got := cr.Certificate().Leaf.Subject.CommonName; got != "renewed.test" {
+		t.Errorf("after a failed reload got %s, want renewed.test", got)
+	}
+	if err = cr.Reload(); err == nil {
+		t.Error("Reload with a broken certificate should return an error")
+	}
+}
+
+func TestClientCA(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ca := newTestCert(t, dir, "ca.test", nil)
+	server := newTestCert(t, dir, "server.test", ca)
+	client := newTestCert(t, dir, "client.test", ca)
+	other := newTestCert(t, dir, "other.test", nil)
+
+	r := New()
+	r.GET("/", func(c *Context) {
+		if cert := c.ClientCertificate(); cert != nil {
+			c.String(cert.Subject.CommonName)
+		}
+	})
+	if err := r.SetClientCA(ca.certFile, true); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := r.tlsConfig(server.certFile, server.keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewUnstartedServer(r)
+	ts.TLS = cfg
+	ts.StartTLS()
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(tc *testCert) (string, error) {
+		tlsCfg := &tls.Config{RootCAs: roots, ServerName: "server.test"}
+		if tc != nil {
+			tlsCfg.Certificates = []tls.Certificate{{Certificate: [][]byte{tc.cert.Raw}, PrivateKey: tc.key}}
+		}
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+		resp, err := c.Get(ts.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return string(b), nil
+	}
+
+	if got, err := get(client); err != nil || got != "client.test" {
+		t.Errorf("trusted client: got %q, %v", got, err)
+	}
+	if _, err = get(nil); err == nil {
+		t.Error("client without certificate was accepted")
+	}
+	if _, err = get(other); err == nil {
+		t.Error("client with an untrusted certificate was accepted")
+	}
+}
@@ -0,0 +1,38 @@
+package gow
+
+import (
+	"github.com/gkzy/gow/upload"
+	"net/url"
+)
+
+// Upload 流式接收上传的文件，不解析整个表单，也不写临时文件
+//	表单中的普通字段可以继续使用 GetString 等方法读取
+//		ret, err := c.Upload(upload.Options{
+//			MaxFileSize:  5 << 20,
+//			AllowedTypes: []string{"image/*"},
+//			Storage:      upload.DirStorage{Dir: "./static/upload"},
+//		})
+//		if err != nil {
+//			c.JSON(gow.H{"code": 1, "msg": err.Error()})
+//			return
+//		}
+//		c.JSON(gow.H{"code": 0, "url": ret.File("avatar").Path})
+func (c *Context) Upload(opts upload.Options) (*upload.Result, error) {
+	ret, err := upload.Receive(c.Req, opts)
+	if err != nil {
+		return nil, err
+	}
+	form := c.input()
+	if form == nil {
+		form = make(url.Values)
+		c.Req.Form = form
+	}
+	if c.Req.PostForm == nil {
+		c.Req.PostForm = make(url.Values)
+	}
+	for k, vs := range ret.Values {
+		form[k] = append(form[k], vs...)
+		c.Req.PostForm[k] = append(c.Req.PostForm[k], vs...)
+	}
+	return ret, nil
+}
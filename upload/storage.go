@@ -0,0 +1,80 @@
+package upload
+
+import (
+	"github.com/gkzy/gow/lib/oss"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage 上传文件的存储
+//	Save 从 r 读取文件内容，返回保存后的路径或 url
+//	r 返回错误时(如超过大小限制)，Save 需要返回错误，并清理已写入的内容
+type Storage interface {
+	Save(name string, r io.Reader) (string, error)
+}
+
+// Remover 上传失败时删除已保存的文件
+type Remover interface {
+	Remove(path string) error
+}
+
+// DirStorage 保存到本地目录
+type DirStorage struct {
+	Dir  string
+	Perm os.FileMode //文件权限，默认为 0644
+}
+
+// Save Save
+func (s DirStorage) Save(name string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return "", err
+	}
+	perm := s.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+	path := filepath.Join(s.Dir, filepath.Base(name))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// Remove Remove
+func (s DirStorage) Remove(path string) error {
+	return os.Remove(path)
+}
+
+// OSSStorage 上传到阿里云 oss
+//	object key 为 Dir/name，name 为 File.Name，返回文件的 url
+//	上传失败时通过 Remove 删除已上传的 object
+type OSSStorage struct {
+	Client *oss.AliClient
+	Dir    string
+}
+
+// Save Save
+func (s OSSStorage) Save(name string, r io.Reader) (string, error) {
+	key := path.Base(filepath.ToSlash(name))
+	if dir := strings.Trim(s.Dir, "/"); dir != "" {
+		key = dir + "/" + key
+	}
+	return s.Client.PutObject(key, r)
+}
+
+// Remove 删除 Save 返回的 url 对应的 object
+func (s OSSStorage) Remove(url string) error {
+	return s.Client.DeleteObject(strings.TrimPrefix(url, s.Client.ServerUrl))
+}
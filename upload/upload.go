@@ -0,0 +1,377 @@
+// Package upload 流式接收 multipart 上传的文件
+//	不解析整个表单，也不写临时文件，每个文件边读边写入 Storage
+//	支持单文件和总大小限制、按文件内容检测类型、生成安全的文件名和上传进度
+//		ret, err := upload.Receive(r, upload.Options{
+//			MaxFileSize:  5 << 20,
+//			AllowedTypes: []string{"image/jpeg", "image/png"},
+//			Storage:      upload.DirStorage{Dir: "./static/upload"},
+//		})
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gkzy/gow/lib/util"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const sniffLen = 512
+
+var (
+	// ErrFileTooLarge 单个文件超过 MaxFileSize
+	ErrFileTooLarge = errors.New("upload: file too large")
+	// ErrTotalTooLarge 请求体超过 MaxTotalSize
+	ErrTotalTooLarge = errors.New("upload: request body too large")
+	// ErrValueTooLarge 普通字段超过 MaxValueSize
+	ErrValueTooLarge = errors.New("upload: form value too large")
+	// ErrTooManyFiles 文件数超过 MaxFiles
+	ErrTooManyFiles = errors.New("upload: too many files")
+	// ErrTypeNotAllowed 文件内容的类型不在 AllowedTypes 中
+	ErrTypeNotAllowed = errors.New("upload: file type not allowed")
+	// ErrNoStorage 没有设置 Storage
+	ErrNoStorage = errors.New("upload: storage is nil")
+)
+
+// Options 上传选项
+type Options struct {
+	MaxFileSize  int64 //单个文件的最大字节数，默认为 10MB
+	MaxTotalSize int64 //整个请求体的最大字节数，默认为 32MB
+	MaxValueSize int64 //普通字段的最大字节数，默认为 1MB
+	MaxFiles     int   //最多接收的文件数，默认为 10
+
+	// AllowedTypes 允许的类型，如 image/png image/* application/pdf
+	//	根据文件的前 512 字节检测，不使用客户端提交的 Content-Type；为空时不限制
+	AllowedTypes []string
+
+	// Fields 接收文件的字段，为空时接收所有字段的文件
+	Fields []string
+
+	// Storage 文件的存储，如 DirStorage OSSStorage
+	Storage Storage
+
+	// Progress 每次写入后调用
+	Progress func(p Progress)
+}
+
+// Progress 上传进度
+type Progress struct {
+	Field         string
+	Filename      string
+	Written       int64 //当前文件已写入的字节数
+	Total         int64 //请求体已读取的字节数
+	ContentLength int64 //请求的 Content-Length，未知时为 -1
+}
+
+// File 已保存的文件
+type File struct {
+	Field       string
+	Filename    string //客户端提交的文件名，只用于展示
+	Name        string //生成的文件名
+	Path        string //Storage 返回的路径或 url
+	Size        int64
+	ContentType string //检测到的类型
+}
+
+// Result 上传结果
+type Result struct {
+	Files  []*File
+	Values url.Values //普通字段
+}
+
+// File 返回 field 的第一个文件
+func (r *Result) File(field string) *File {
+	for _, f := range r.Files {
+		if f.Field == field {
+			return f
+		}
+	}
+	return nil
+}
+
+// FileError 处理文件失败
+type FileError struct {
+	Field    string
+	Filename string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	return "upload: field " + e.Field + " file " + e.Filename + ": " + e.Err.Error()
+}
+
+// Unwrap Unwrap
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// prepare 设置默认值
+func (o Options) prepare() Options {
+	if o.MaxFileSize <= 0 {
+		o.MaxFileSize = 10 << 20
+	}
+	if o.MaxTotalSize <= 0 {
+		o.MaxTotalSize = 32 << 20
+	}
+	if o.MaxValueSize <= 0 {
+		o.MaxValueSize = 1 << 20
+	}
+	if o.MaxFiles <= 0 {
+		o.MaxFiles = 10
+	}
+	return o
+}
+
+// Receive 读取 multipart 请求，把文件写入 opts.Storage
+//	失败时删除已保存的文件(Storage 实现 Remover 时)
+func Receive(r *http.Request, opts Options) (*Result, error) {
+	opts = opts.prepare()
+	if opts.Storage == nil {
+		return nil, ErrNoStorage
+	}
+	if r.ContentLength > opts.MaxTotalSize {
+		return nil, ErrTotalTooLarge
+	}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, http.ErrNotMultipart
+	}
+
+	rc := &receiver{
+		opts:   opts,
+		length: r.ContentLength,
+		ret:    &Result{Values: make(url.Values)},
+	}
+	rc.body = &limitReader{r: r.Body, n: opts.MaxTotalSize, err: ErrTotalTooLarge, count: &rc.total}
+	if err = rc.receive(multipart.NewReader(rc.body, params["boundary"])); err != nil {
+		rc.cleanup()
+		return nil, err
+	}
+	return rc.ret, nil
+}
+
+// receiver 一次上传的状态
+type receiver struct {
+	opts   Options
+	body   *limitReader
+	length int64
+	total  int64
+	ret    *Result
+}
+
+func (rc *receiver) receive(mr *multipart.Reader) error {
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return rc.bodyError(err)
+		}
+		field := part.FormName()
+		if field == "" {
+			part.Close()
+			continue
+		}
+		if part.FileName() == "" {
+			err = rc.readValue(field, part)
+		} else if rc.accept(field) {
+			err = rc.saveFile(field, part)
+		}
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// bodyError 请求体超过限制时返回 ErrTotalTooLarge
+func (rc *receiver) bodyError(err error) error {
+	if rc.body.exceeded {
+		return ErrTotalTooLarge
+	}
+	return err
+}
+
+// accept 是否接收 field 的文件
+func (rc *receiver) accept(field string) bool {
+	if len(rc.opts.Fields) == 0 {
+		return true
+	}
+	for _, f := range rc.opts.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// readValue 读取普通字段
+func (rc *receiver) readValue(field string, part *multipart.Part) error {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, part, rc.opts.MaxValueSize+1)
+	if err != nil && err != io.EOF {
+		return rc.bodyError(err)
+	}
+	if n > rc.opts.MaxValueSize {
+		return &FileError{Field: field, Err: ErrValueTooLarge}
+	}
+	rc.ret.Values.Add(field, buf.String())
+	return nil
+}
+
+// saveFile 检测类型，生成文件名，写入 Storage
+func (rc *receiver) saveFile(field string, part *multipart.Part) error {
+	filename := filepath.Base(strings.Replace(part.FileName(), "\\", "/", -1))
+	fail := func(err error) error {
+		return &FileError{Field: field, Filename: filename, Err: err}
+	}
+	if len(rc.ret.Files) >= rc.opts.MaxFiles {
+		return fail(ErrTooManyFiles)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return fail(rc.bodyError(err))
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !allowed(rc.opts.AllowedTypes, contentType) {
+		return fail(ErrTypeNotAllowed)
+	}
+
+	file := &File{
+		Field:       field,
+		Filename:    filename,
+		Name:        safeName(filename, contentType),
+		ContentType: contentType,
+	}
+	src := &limitReader{
+		r:     io.MultiReader(bytes.NewReader(head), part),
+		n:     rc.opts.MaxFileSize,
+		err:   ErrFileTooLarge,
+		count: &file.Size,
+	}
+	if rc.opts.Progress != nil {
+		src.progress = func() {
+			rc.opts.Progress(Progress{
+				Field:         field,
+				Filename:      filename,
+				Written:       file.Size,
+				Total:         rc.total,
+				ContentLength: rc.length,
+			})
+		}
+	}
+	if file.Path, err = rc.opts.Storage.Save(file.Name, src); err != nil {
+		if src.exceeded {
+			err = src.err
+		}
+		return fail(rc.bodyError(err))
+	}
+	rc.ret.Files = append(rc.ret.Files, file)
+	return nil
+}
+
+// cleanup 删除已保存的文件
+func (rc *receiver) cleanup() {
+	rm, ok := rc.opts.Storage.(Remover)
+	if !ok {
+		return
+	}
+	for _, f := range rc.ret.Files {
+		rm.Remove(f.Path)
+	}
+}
+
+// allowed contentType 是否在 types 中
+func allowed(types []string, contentType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "*/*" || t == mediaType {
+			return true
+		}
+		if strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeName 生成随机文件名
+//	扩展名与检测到的类型一致时使用原扩展名，否则使用类型的扩展名
+func safeName(filename, contentType string) string {
+	id, _ := util.GetUUID()
+	name := time.Now().Format("20060102") + id
+	ext := strings.ToLower(filepath.Ext(filename))
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	exts, _ := mime.ExtensionsByType(mediaType)
+	for _, e := range exts {
+		if e == ext {
+			return name + ext
+		}
+	}
+	if len(exts) > 0 {
+		return name + exts[0]
+	}
+	if mediaType == "application/octet-stream" || !validExt(ext) {
+		return name
+	}
+	return name + ext
+}
+
+// validExt 扩展名只包含字母和数字
+func validExt(ext string) bool {
+	if len(ext) < 2 || len(ext) > 10 {
+		return false
+	}
+	for _, ch := range ext[1:] {
+		if !('a' <= ch && ch <= 'z' || '0' <= ch && ch <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// limitReader 超过 n 字节时返回 err，并把读取的字节数累加到 count
+type limitReader struct {
+	r        io.Reader
+	n        int64
+	err      error
+	count    *int64
+	read     int64
+	exceeded bool
+	progress func()
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, l.err
+	}
+	// 最多多读一个字节，用来判断是否超过限制
+	if max := l.n - l.read + 1; int64(len(p)) > max {
+		p = p[:max]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.n {
+		l.exceeded = true
+		return 0, l.err
+	}
+	*l.count += int64(n)
+	if n > 0 && l.progress != nil {
+		l.progress()
+	}
+	return n, err
+}
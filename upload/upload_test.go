@@ -0,0 +1,184 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type part struct {
+	field, filename string
+	data            []byte
+}
+
+func newRequest(t *testing.T, parts ...part) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		if p.filename == "" {
+			w.WriteField(p.field, string(p.data))
+			continue
+		}
+		fw, err := w.CreateFormFile(p.field, p.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(p.data)
+	}
+	w.Close()
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestReceive(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "upload")
+	defer os.RemoveAll(dir)
+
+	img := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte("x"), 2000)...)
+	req := newRequest(t,
+		part{field: "title", data: []byte("avatar")},
+		part{field: "file", filename: `..\..\evil.php`, data: img},
+	)
+	var last Progress
+	ret, err := Receive(req, Options{
+		AllowedTypes: []string{"image/*"},
+		Storage:      DirStorage{Dir: dir},
+		Progress:     func(p Progress) { last = p },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.Values.Get("title") != "avatar" {
+		t.Fatalf("title = %q", ret.Values.Get("title"))
+	}
+	f := ret.File("file")
+	if f == nil || f.Filename != "evil.php" || f.ContentType != "image/png" || f.Size != int64(len(img)) {
+		t.Fatalf("file = %+v", f)
+	}
+	if filepath.Dir(f.Path) != dir || !strings.HasSuffix(f.Name, ".png") {
+		t.Fatalf("unsafe path %s", f.Path)
+	}
+	if data, _ := ioutil.ReadFile(f.Path); !bytes.Equal(data, img) {
+		t.Fatal("saved content mismatch")
+	}
+	if last.Written != f.Size || last.Field != "file" {
+		t.Fatalf("progress = %+v", last)
+	}
+}
+
+func TestReceive_Limits(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "upload")
+	defer os.RemoveAll(dir)
+	img := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte("x"), 100)...)
+
+	cases := []struct {
+		name  string
+		parts []part
+		opts  Options
+		want  error
+	}{
+		{
+			name:  "type",
+			parts: []part{{field: "file", filename: "a.png", data: []byte("<html><script>")}},
+			opts:  Options{AllowedTypes: []string{"image/png"}},
+			want:  ErrTypeNotAllowed,
+		},
+		{
+			name:  "file size",
+			parts: []part{{field: "a", filename: "a.png", data: img}, {field: "b", filename: "b.png", data: append(img, 'x')}},
+			opts:  Options{MaxFileSize: int64(len(img))},
+			want:  ErrFileTooLarge,
+		},
+		{
+			name:  "total size",
+			parts: []part{{field: "a", filename: "a.png", data: img}, {field: "b", filename: "b.png", data: img}},
+			opts:  Options{MaxTotalSize: int64(len(img)) + 300},
+			want:  ErrTotalTooLarge,
+		},
+		{
+			name:  "files",
+			parts: []part{{field: "a", filename: "a.png", data: img}, {field: "b", filename: "b.png", data: img}},
+			opts:  Options{MaxFiles: 1},
+			want:  ErrTooManyFiles,
+		},
+	}
+	for _, tc := range cases {
+		req := newRequest(t, tc.parts...)
+		req.ContentLength = -1
+		tc.opts.Storage = DirStorage{Dir: dir}
+		if _, err := Receive(req, tc.opts); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: want %v, got %v", tc.name, tc.want, err)
+		}
+		// 失败时删除已保存的文件
+		if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+			t.Fatalf("%s: %d files left", tc.name, len(files))
+		}
+	}
+}
+
+// memStorage 保存在内存中，第 failAt 个文件保存失败
+type memStorage struct {
+	files   map[string][]byte
+	removed []string
+	failAt  int
+	saved   int
+}
+
+func (s *memStorage) Save(name string, r io.Reader) (string, error) {
+	s.saved++
+	if s.saved == s.failAt {
+		return "", errors.New("storage unavailable")
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	path := "mem://" + name
+	s.files[path] = data
+	return path, nil
+}
+
+func (s *memStorage) Remove(path string) error {
+	s.removed = append(s.removed, path)
+	delete(s.files, path)
+	return nil
+}
+
+func TestReceive_Cleanup(t *testing.T) {
+	img := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte("x"), 100)...)
+	parts := []part{
+		{field: "a", filename: "a.png", data: img},
+		{field: "b", filename: "b.png", data: img},
+		{field: "c", filename: "c.png", data: img},
+	}
+
+	// 第三个文件保存失败时，删除前两个
+	s := &memStorage{files: make(map[string][]byte), failAt: 3}
+	if _, err := Receive(newRequest(t, parts...), Options{Storage: s}); err == nil {
+		t.Fatal("want error when the storage fails")
+	}
+	if len(s.removed) != 2 || len(s.files) != 0 {
+		t.Fatalf("removed %v, %d files left", s.removed, len(s.files))
+	}
+
+	// 成功时不删除
+	s = &memStorage{files: make(map[string][]byte)}
+	ret, err := Receive(newRequest(t, parts...), Options{Storage: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ret.Files) != 3 || len(s.files) != 3 || len(s.removed) != 0 {
+		t.Fatalf("files %d, stored %d, removed %v", len(ret.Files), len(s.files), s.removed)
+	}
+}
@@ -0,0 +1,87 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TagError validate tag 不正确，如规则不存在、参数不是数字、引用的字段不存在
+//	第一次校验该结构体时返回，不会执行任何规则
+type TagError struct {
+	Struct string // 结构体类型，如 main.UserRequest
+	Field  string // 结构体中的字段名
+	Rule   string // 出错的规则
+	Param  string // 规则的参数
+	Reason string
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("validate: %s on field %s.%s: %s", e.rule(), e.Struct, e.Field, e.Reason)
+}
+
+func (e *TagError) rule() string {
+	if e.Param == "" {
+		return fmt.Sprintf("rule %q", e.Rule)
+	}
+	return fmt.Sprintf("rule %q", e.Rule+"="+e.Param)
+}
+
+// FieldError 字段校验失败
+type FieldError struct {
+	Field      string      // 结构体中的字段，嵌套时为 Parent.Field 或 Items[0].Field
+	Name       string      // 请求中的名称，依次使用 json form query uri header tag
+	Label      string      // label tag，错误消息中使用，为空时使用 Name
+	Rule       string      // 失败的规则
+	Param      string      // 规则的参数
+	ParamLabel string      // 跨字段规则中另一个字段的名称
+	Value      interface{} // 字段的值
+
+	kind reflect.Kind
+	v    *Validator
+}
+
+// Error 使用 Validator.Lang 的消息
+func (e *FieldError) Error() string {
+	return e.Translate(e.v.Lang)
+}
+
+// Translate 返回 lang 的错误消息，没有 lang 的消息时使用主语言(zh-CN 使用 zh)，都没有时使用 en
+//		fe.Translate("zh") // 用户名长度不能小于2个字符
+func (e *FieldError) Translate(lang string) string {
+	field := e.Label
+	if field == "" {
+		field = e.Name
+	}
+	param := e.Param
+	if e.ParamLabel != "" {
+		param = e.ParamLabel
+	}
+	return strings.NewReplacer(
+		"{field}", field,
+		"{param}", param,
+		"{value}", fmt.Sprint(e.Value),
+	).Replace(e.v.message(lang, e.Rule, e.kind))
+}
+
+// Errors 所有校验失败的字段
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Translate 返回 Field -> 错误消息
+//	使用完整的字段路径，如 Items[1].City，slice 中的元素和同名的嵌套字段不会互相覆盖
+//		c.ServerJSON(400, errs.Translate("zh"))
+func (e Errors) Translate(lang string) map[string]string {
+	ret := make(map[string]string, len(e))
+	for _, fe := range e {
+		ret[fe.Field] = fe.Translate(lang)
+	}
+	return ret
+}
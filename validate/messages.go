@@ -0,0 +1,85 @@
+package validate
+
+// builtinMessages 内置规则的错误消息
+var builtinMessages = map[string]map[string]string{
+	"en": {
+		"default":          "{field} is invalid",
+		"required":         "{field} is required",
+		"required_with":    "{field} is required when {param} is present",
+		"required_without": "{field} is required when {param} is absent",
+		"len":              "{field} must be equal to {param}",
+		"len_len":          "{field} must be {param} characters or items long",
+		"min":              "{field} must be {param} or greater",
+		"min_len":          "{field} must be at least {param} characters or items long",
+		"max":              "{field} must be {param} or less",
+		"max_len":          "{field} must be at most {param} characters or items long",
+		"eq":               "{field} must be equal to {param}",
+		"ne":               "{field} must not be equal to {param}",
+		"gt":               "{field} must be greater than {param}",
+		"gt_len":           "{field} must be longer than {param} characters or items",
+		"gte":              "{field} must be {param} or greater",
+		"gte_len":          "{field} must be at least {param} characters or items long",
+		"lt":               "{field} must be less than {param}",
+		"lt_len":           "{field} must be shorter than {param} characters or items",
+		"lte":              "{field} must be {param} or less",
+		"lte_len":          "{field} must be at most {param} characters or items long",
+		"oneof":            "{field} must be one of [{param}]",
+		"eqfield":          "{field} must be equal to {param}",
+		"nefield":          "{field} must not be equal to {param}",
+		"gtfield":          "{field} must be greater than {param}",
+		"gtefield":         "{field} must be greater than or equal to {param}",
+		"ltfield":          "{field} must be less than {param}",
+		"ltefield":         "{field} must be less than or equal to {param}",
+		"email":            "{field} must be a valid email address",
+		"url":              "{field} must be a valid URL",
+		"ip":               "{field} must be a valid IP address",
+		"ipv4":             "{field} must be a valid IPv4 address",
+		"ipv6":             "{field} must be a valid IPv6 address",
+		"numeric":          "{field} must be numeric",
+		"alpha":            "{field} must contain only letters",
+		"alphanum":         "{field} must contain only letters and digits",
+		"datetime":         "{field} must match the format {param}",
+		"mobile":           "{field} must be a valid mobile number",
+		"idcard":           "{field} must be a valid ID card number",
+	},
+	"zh": {
+		"default":          "{field}格式不正确",
+		"required":         "{field}不能为空",
+		"required_with":    "{param}不为空时，{field}不能为空",
+		"required_without": "{param}为空时，{field}不能为空",
+		"len":              "{field}必须等于{param}",
+		"len_len":          "{field}长度必须为{param}",
+		"min":              "{field}不能小于{param}",
+		"min_len":          "{field}长度不能小于{param}",
+		"max":              "{field}不能大于{param}",
+		"max_len":          "{field}长度不能大于{param}",
+		"eq":               "{field}必须等于{param}",
+		"ne":               "{field}不能等于{param}",
+		"gt":               "{field}必须大于{param}",
+		"gt_len":           "{field}长度必须大于{param}",
+		"gte":              "{field}不能小于{param}",
+		"gte_len":          "{field}长度不能小于{param}",
+		"lt":               "{field}必须小于{param}",
+		"lt_len":           "{field}长度必须小于{param}",
+		"lte":              "{field}不能大于{param}",
+		"lte_len":          "{field}长度不能大于{param}",
+		"oneof":            "{field}必须是[{param}]中的一个",
+		"eqfield":          "{field}必须等于{param}",
+		"nefield":          "{field}不能等于{param}",
+		"gtfield":          "{field}必须大于{param}",
+		"gtefield":         "{field}不能小于{param}",
+		"ltfield":          "{field}必须小于{param}",
+		"ltefield":         "{field}不能大于{param}",
+		"email":            "{field}必须是有效的邮箱地址",
+		"url":              "{field}必须是有效的URL",
+		"ip":               "{field}必须是有效的IP地址",
+		"ipv4":             "{field}必须是有效的IPv4地址",
+		"ipv6":             "{field}必须是有效的IPv6地址",
+		"numeric":          "{field}只能是数字",
+		"alpha":            "{field}只能包含字母",
+		"alphanum":         "{field}只能包含字母和数字",
+		"datetime":         "{field}的格式必须为{param}",
+		"mobile":           "{field}必须是有效的手机号码",
+		"idcard":           "{field}必须是有效的身份证号码",
+	},
+}
@@ -0,0 +1,284 @@
+package validate
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	mobileRegexp   = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	numericRegexp  = regexp.MustCompile(`^[-+]?\d+(\.\d+)?$`)
+	alphaRegexp    = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alphanumRegexp = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+	// idCardWeights 身份证前17位的加权因子
+	idCardWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	// idCardCheckCodes 校验码
+	idCardCheckCodes = "10X98765432"
+)
+
+// builtinRules 内置规则
+var builtinRules = map[string]RuleFunc{
+	"required":         required,
+	"required_with":    requiredWith,
+	"required_without": requiredWithout,
+	"len":              compareParam(func(n, p float64) bool { return n == p }),
+	"min":              compareParam(func(n, p float64) bool { return n >= p }),
+	"max":              compareParam(func(n, p float64) bool { return n <= p }),
+	"gt":               compareParam(func(n, p float64) bool { return n > p }),
+	"gte":              compareParam(func(n, p float64) bool { return n >= p }),
+	"lt":               compareParam(func(n, p float64) bool { return n < p }),
+	"lte":              compareParam(func(n, p float64) bool { return n <= p }),
+	"eq":               eq,
+	"ne":               func(fl *FieldLevel) bool { return !eq(fl) },
+	"oneof":            oneOf,
+	"eqfield":          compareField(func(c int) bool { return c == 0 }),
+	"nefield":          compareField(func(c int) bool { return c != 0 }),
+	"gtfield":          compareField(func(c int) bool { return c > 0 }),
+	"gtefield":         compareField(func(c int) bool { return c >= 0 }),
+	"ltfield":          compareField(func(c int) bool { return c < 0 }),
+	"ltefield":         compareField(func(c int) bool { return c <= 0 }),
+	"email":            isString(isEmail),
+	"url":              isString(isURL),
+	"ip":               isString(func(s string) bool { return net.ParseIP(s) != nil }),
+	"ipv4":             isString(func(s string) bool { ip := net.ParseIP(s); return ip != nil && ip.To4() != nil }),
+	"ipv6":             isString(func(s string) bool { ip := net.ParseIP(s); return ip != nil && ip.To4() == nil }),
+	"numeric":          isString(numericRegexp.MatchString),
+	"alpha":            isString(alphaRegexp.MatchString),
+	"alphanum":         isString(alphanumRegexp.MatchString),
+	"mobile":           isString(mobileRegexp.MatchString),
+	"idcard":           isString(IsIDCard),
+	"datetime":         datetime,
+}
+
+// numericParamRules 参数必须为数字的规则
+var numericParamRules = map[string]bool{
+	"len": true,
+	"min": true,
+	"max": true,
+	"gt":  true,
+	"gte": true,
+	"lt":  true,
+	"lte": true,
+}
+
+// crossFieldRules 参数为另一个字段的规则
+var crossFieldRules = map[string]bool{
+	"required_with":    true,
+	"required_without": true,
+	"eqfield":          true,
+	"nefield":          true,
+	"gtfield":          true,
+	"gtefield":         true,
+	"ltfield":          true,
+	"ltefield":         true,
+}
+
+// IsIDCard 校验18位身份证号码 (GB 11643-1999)
+//	校验出生日期和最后一位校验码
+func IsIDCard(s string) bool {
+	if len(s) != 18 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+		sum += int(s[i]-'0') * idCardWeights[i]
+	}
+	birth, err := time.Parse("20060102", s[6:14])
+	if err != nil || birth.After(time.Now()) {
+		return false
+	}
+	return strings.ToUpper(s[17:]) == string(idCardCheckCodes[sum%11])
+}
+
+// IsMobile 校验中国大陆手机号码
+func IsMobile(s string) bool {
+	return mobileRegexp.MatchString(s)
+}
+
+// required 不能为零值
+func required(fl *FieldLevel) bool {
+	return !isZero(fl.Field)
+}
+
+// requiredWith 另一个字段不为零值时，不能为零值
+func requiredWith(fl *FieldLevel) bool {
+	other := fl.Parent.FieldByName(fl.Param)
+	if !other.IsValid() || isZero(other) {
+		return true
+	}
+	return !isZero(fl.Field)
+}
+
+// requiredWithout 另一个字段为零值时，不能为零值
+func requiredWithout(fl *FieldLevel) bool {
+	other := fl.Parent.FieldByName(fl.Param)
+	if other.IsValid() && !isZero(other) {
+		return true
+	}
+	return !isZero(fl.Field)
+}
+
+// compareParam 与参数比较
+//	string slice map 比较长度(字符数)，数字比较值
+func compareParam(fn func(n, p float64) bool) RuleFunc {
+	return func(fl *FieldLevel) bool {
+		p, err := strconv.ParseFloat(fl.Param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validate: invalid param %q on field %s", fl.Param, fl.Name))
+		}
+		n, ok := number(fl.Field)
+		if !ok {
+			return false
+		}
+		return fn(n, p)
+	}
+}
+
+// eq 等于参数
+func eq(fl *FieldLevel) bool {
+	v := indirect(fl.Field)
+	if v.Kind() == reflect.String {
+		return v.String() == fl.Param
+	}
+	return compareParam(func(n, p float64) bool { return n == p })(fl)
+}
+
+// oneOf 参数中的一个，以空格分隔
+func oneOf(fl *FieldLevel) bool {
+	s := fmt.Sprint(interfaceOf(indirect(fl.Field)))
+	for _, p := range strings.Fields(fl.Param) {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// compareField 与另一个字段比较
+//	数字比较值，time.Time 比较时间，string 按字典序比较
+func compareField(fn func(c int) bool) RuleFunc {
+	return func(fl *FieldLevel) bool {
+		other := fl.Parent.FieldByName(fl.Param)
+		if !other.IsValid() {
+			panic(fmt.Sprintf("validate: field %s not found for %s", fl.Param, fl.Name))
+		}
+		c, ok := compare(indirect(fl.Field), indirect(other))
+		return ok && fn(c)
+	}
+}
+
+// compare 返回 -1 0 1，不能比较时返回 false
+func compare(a, b reflect.Value) (int, bool) {
+	if a.Type() == timeType && b.Type() == timeType {
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		}
+		return 0, true
+	}
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+	na, ok1 := numberValue(a)
+	nb, ok2 := numberValue(b)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	switch {
+	case na < nb:
+		return -1, true
+	case na > nb:
+		return 1, true
+	}
+	return 0, true
+}
+
+// datetime 按参数中的格式解析
+func datetime(fl *FieldLevel) bool {
+	v := indirect(fl.Field)
+	if v.Kind() != reflect.String {
+		return false
+	}
+	_, err := time.Parse(fl.Param, v.String())
+	return err == nil
+}
+
+// isString 字符串规则
+func isString(fn func(s string) bool) RuleFunc {
+	return func(fl *FieldLevel) bool {
+		v := indirect(fl.Field)
+		return v.Kind() == reflect.String && fn(v.String())
+	}
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func isURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// number string slice map 返回长度，数字返回值
+func number(v reflect.Value) (float64, bool) {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return numberValue(v)
+}
+
+// numberValue 数字的值
+func numberValue(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// indirect 返回指针指向的值
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// isZero 是否为零值，nil 指针为零值
+func isZero(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,402 @@
+// Package validate 根据 validate tag 校验结构体
+//	type UserRequest struct {
+//		Name     string `json:"name" label:"用户名" validate:"required,min=2,max=20"`
+//		Mobile   string `json:"mobile" label:"手机号" validate:"required,mobile"`
+//		IDCard   string `json:"id_card" validate:"omitempty,idcard"`
+//		Age      int    `json:"age" validate:"gte=18,lte=120"`
+//		Password string `json:"password" validate:"required,min=6"`
+//		Confirm  string `json:"confirm" validate:"eqfield=Password"`
+//	}
+//
+//	if err := validate.Struct(&req); err != nil {
+//		errs := err.(validate.Errors)
+//		fmt.Println(errs.Translate("zh"))
+//	}
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tagName   = "validate"
+	labelName = "label"
+)
+
+// ErrInvalidObj obj 不是结构体或结构体指针
+var ErrInvalidObj = errors.New("validate: obj must be a struct or a pointer to struct")
+
+// FieldLevel 传给 RuleFunc 的字段信息
+type FieldLevel struct {
+	Parent reflect.Value // 字段所在的结构体
+	Field  reflect.Value // 字段的值
+	Name   string        // 结构体中的字段名
+	Param  string        // rule 的参数，如 min=2 中的 2
+}
+
+// RuleFunc 校验规则，返回 false 表示校验失败
+type RuleFunc func(fl *FieldLevel) bool
+
+// Validator 校验器
+type Validator struct {
+	// Lang Error() 使用的语言，默认为 en
+	Lang string
+
+	mu       sync.RWMutex
+	rules    map[string]RuleFunc
+	custom   map[string]bool // 通过 RegisterRule 注册的规则，不检查参数
+	messages map[string]map[string]string
+	checked  map[reflect.Type]error // 已检查过 tag 的结构体
+}
+
+// Default 默认的校验器，binding 使用此校验器
+var Default = New()
+
+// New return a validator with built-in rules and messages
+func New() *Validator {
+	v := &Validator{
+		Lang:     "en",
+		rules:    make(map[string]RuleFunc),
+		custom:   make(map[string]bool),
+		messages: make(map[string]map[string]string),
+		checked:  make(map[reflect.Type]error),
+	}
+	for name, fn := range builtinRules {
+		v.rules[name] = fn
+	}
+	for lang, msgs := range builtinMessages {
+		for rule, msg := range msgs {
+			v.RegisterMessage(lang, rule, msg)
+		}
+	}
+	return v
+}
+
+// Struct 使用 Default 校验 obj
+func Struct(obj interface{}) error {
+	return Default.Struct(obj)
+}
+
+// RegisterRule 注册自定义规则，同名时覆盖内置规则
+//		validate.RegisterRule("username", func(fl *validate.FieldLevel) bool {
+//			return usernameRegexp.MatchString(fl.Field.String())
+//		}, map[string]string{"zh": "{field}只能包含字母和数字", "en": "{field} must contain only letters and digits"})
+func RegisterRule(name string, fn RuleFunc, messages ...map[string]string) {
+	Default.RegisterRule(name, fn, messages...)
+}
+
+// RegisterRule 注册自定义规则和多语言错误消息
+func (v *Validator) RegisterRule(name string, fn RuleFunc, messages ...map[string]string) {
+	v.mu.Lock()
+	v.rules[name] = fn
+	v.custom[name] = true
+	// 之前因为规则不存在而检查失败的结构体需要重新检查
+	v.checked = make(map[reflect.Type]error)
+	v.mu.Unlock()
+	for _, msgs := range messages {
+		for lang, msg := range msgs {
+			v.RegisterMessage(lang, name, msg)
+		}
+	}
+}
+
+// RegisterMessage 注册或覆盖错误消息
+//	{field} 替换为字段名称，{param} 替换为规则参数
+//	min max len 等规则作用于 string slice map 时，使用 rule_len 的消息，如 min_len
+func (v *Validator) RegisterMessage(lang, rule, msg string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.messages[lang] == nil {
+		v.messages[lang] = make(map[string]string)
+	}
+	v.messages[lang][rule] = msg
+}
+
+// ValidateStruct 实现 binding.StructValidator
+func (v *Validator) ValidateStruct(obj interface{}) error {
+	return v.Struct(obj)
+}
+
+// Struct 校验 obj，失败时返回 Errors
+//	会校验嵌套的结构体，以及 slice map 中的结构体
+//	validate tag 不正确时返回 *TagError
+func (v *Validator) Struct(obj interface{}) error {
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return ErrInvalidObj
+	}
+	var errs Errors
+	if err := v.validateStruct(val, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct 校验结构体的每个字段
+func (v *Validator) validateStruct(parent reflect.Value, prefix string, errs *Errors) error {
+	t := parent.Type()
+	if err := v.checkTags(t); err != nil {
+		return err
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		value := parent.Field(i)
+		path := prefix + field.Name
+		if field.Anonymous {
+			path = strings.TrimSuffix(prefix, ".")
+		}
+
+		tag := field.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		if tag != "" && !v.validateField(parent, field, value, prefix+field.Name, tag, errs) {
+			continue
+		}
+		if err := v.dive(value, path, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dive 校验嵌套的结构体
+func (v *Validator) dive(value reflect.Value, path string, errs *Errors) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	prefix := path
+	if prefix != "" {
+		prefix += "."
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return nil
+		}
+		return v.validateStruct(value, prefix, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.dive(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			if err := v.dive(value.MapIndex(key), fmt.Sprintf("%s[%v]", path, key.Interface()), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkTags 检查结构体 t 中所有字段的 validate tag，结果按类型缓存
+//	在执行规则前发现不存在的规则和不正确的参数，避免校验请求时 panic
+func (v *Validator) checkTags(t reflect.Type) error {
+	v.mu.RLock()
+	err, ok := v.checked[t]
+	v.mu.RUnlock()
+	if ok {
+		return err
+	}
+
+	err = v.parseTags(t)
+	v.mu.Lock()
+	v.checked[t] = err
+	v.mu.Unlock()
+	return err
+}
+
+// parseTags 解析并检查结构体 t 的 validate tag
+func (v *Validator) parseTags(t reflect.Type) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get(tagName)
+		if tag == "" || tag == "-" {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			name, param := splitRule(rule)
+			if name == "" || name == "omitempty" {
+				continue
+			}
+			tagErr := func(reason string) error {
+				return &TagError{Struct: t.String(), Field: field.Name, Rule: name, Param: param, Reason: reason}
+			}
+			if _, ok := v.rules[name]; !ok {
+				return tagErr("undefined rule")
+			}
+			if v.custom[name] {
+				continue
+			}
+			switch {
+			case crossFieldRules[name]:
+				if _, ok := t.FieldByName(param); !ok {
+					return tagErr(fmt.Sprintf("field %q not found", param))
+				}
+			case numericParamRules[name] || (name == "eq" || name == "ne") && !stringType(field.Type):
+				if _, err := strconv.ParseFloat(param, 64); err != nil {
+					return tagErr("param must be a number")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// splitRule 把 min=2 拆分为 min 和 2
+func splitRule(rule string) (name, param string) {
+	name = strings.TrimSpace(rule)
+	if i := strings.IndexByte(name, '='); i >= 0 {
+		name, param = name[:i], name[i+1:]
+	}
+	return
+}
+
+// stringType eq ne 按字符串比较的类型，interface 在校验时才能确定
+func stringType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String || t.Kind() == reflect.Interface
+}
+
+// validateField 按顺序执行字段的规则，遇到第一个失败的规则时停止
+//	返回 false 表示字段校验失败
+func (v *Validator) validateField(parent reflect.Value, field reflect.StructField, value reflect.Value, path, tag string, errs *Errors) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param := splitRule(rule)
+		if name == "" {
+			continue
+		}
+		if name == "omitempty" {
+			if isZero(value) {
+				return true
+			}
+			continue
+		}
+
+		// checkTags 已检查规则存在
+		v.mu.RLock()
+		fn := v.rules[name]
+		v.mu.RUnlock()
+		fl := &FieldLevel{Parent: parent, Field: value, Name: field.Name, Param: param}
+		if fn(fl) {
+			continue
+		}
+
+		fe := &FieldError{
+			Field: path,
+			Name:  fieldName(field),
+			Label: field.Tag.Get(labelName),
+			Rule:  name,
+			Param: param,
+			Value: interfaceOf(value),
+			kind:  indirect(value).Kind(),
+			v:     v,
+		}
+		if crossFieldRules[name] {
+			if other, ok := parent.Type().FieldByName(param); ok {
+				fe.ParamLabel = other.Tag.Get(labelName)
+				if fe.ParamLabel == "" {
+					fe.ParamLabel = fieldName(other)
+				}
+			}
+		}
+		*errs = append(*errs, fe)
+		return false
+	}
+	return true
+}
+
+// message 返回 lang 中的消息，没有时使用 en
+func (v *Validator) message(lang, rule string, kind reflect.Kind) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, l := range fallbackLangs(lang) {
+		msgs := v.messages[l]
+		if msgs == nil {
+			continue
+		}
+		switch kind {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			if msg, ok := msgs[rule+"_len"]; ok {
+				return msg
+			}
+		}
+		if msg, ok := msgs[rule]; ok {
+			return msg
+		}
+		if msg, ok := msgs["default"]; ok {
+			return msg
+		}
+	}
+	return "{field} is invalid"
+}
+
+// Message 返回 lang 中 rule 的错误消息，{field} 替换为 field
+//		validate.Default.Message("zh", "default", "age") // age格式不正确
+func (v *Validator) Message(lang, rule, field string) string {
+	return strings.Replace(v.message(lang, rule, reflect.Invalid), "{field}", field, -1)
+}
+
+// fallbackLangs 依次使用 lang、小写的 lang、主语言(zh-CN 使用 zh)和 en
+func fallbackLangs(lang string) []string {
+	langs := []string{lang}
+	l := strings.ToLower(strings.Replace(lang, "_", "-", -1))
+	if l != lang {
+		langs = append(langs, l)
+	}
+	if i := strings.IndexByte(l, '-'); i > 0 {
+		langs = append(langs, l[:i])
+	}
+	return append(langs, "en")
+}
+
+// fieldName 请求中的字段名称，依次使用 json form query uri header tag
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query", "uri", "header", "xml"} {
+		name := field.Tag.Get(tag)
+		if i := strings.IndexByte(name, ','); i >= 0 {
+			name = name[:i]
+		}
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// interfaceOf 返回字段的值
+func interfaceOf(value reflect.Value) interface{} {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
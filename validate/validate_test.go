@@ -0,0 +1,189 @@
+package validate
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type userRequest struct {
+	Name     string     `json:"name" label:"用户名" validate:"required,min=2,max=8"`
+	Mobile   string     `json:"mobile" validate:"required,mobile"`
+	IDCard   string     `json:"id_card" validate:"omitempty,idcard"`
+	Age      int        `json:"age" validate:"gte=18,lte=120"`
+	Gender   string     `json:"gender" validate:"oneof=male female"`
+	Password string     `json:"password" label:"密码" validate:"required"`
+	Confirm  string     `json:"confirm" label:"确认密码" validate:"eqfield=Password"`
+	Email    string     `json:"email" validate:"required_without=Mobile,omitempty,email"`
+	Address  *address   `json:"address"`
+	Items    []*address `json:"items"`
+}
+
+func valid() userRequest {
+	return userRequest{
+		Name:     "gow",
+		Mobile:   "13800138000",
+		IDCard:   "11010519491231002X",
+		Age:      20,
+		Gender:   "male",
+		Password: "123456",
+		Confirm:  "123456",
+	}
+}
+
+func TestStruct(t *testing.T) {
+	u := valid()
+	if err := Struct(&u); err != nil {
+		t.Fatalf("want nil, got %v", err)
+	}
+
+	u.Name = "g"
+	u.Mobile = "12800138000"
+	u.IDCard = "110105194912310021"
+	u.Age = 10
+	u.Gender = "x"
+	u.Confirm = "654321"
+	u.Address = &address{}
+	u.Items = []*address{{City: "sz"}, {}}
+	err := Struct(u)
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("want Errors, got %v", err)
+	}
+
+	var fields []string
+	for _, fe := range errs {
+		fields = append(fields, fe.Field+":"+fe.Rule)
+	}
+	want := []string{"Name:min", "Mobile:mobile", "IDCard:idcard", "Age:gte", "Gender:oneof", "Confirm:eqfield", "Address.City:required", "Items[1].City:required"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("got %v\nwant %v", fields, want)
+	}
+
+	msgs := errs.Translate("zh")
+	if msgs["Name"] != "用户名长度不能小于2" || msgs["Confirm"] != "确认密码必须等于密码" || msgs["Address.City"] == "" || msgs["Items[1].City"] == "" {
+		t.Fatalf("unexpected zh messages: %v", msgs)
+	}
+	if errs[0].Error() != "用户名 must be at least 2 characters or items long" {
+		t.Fatalf("unexpected en message: %s", errs[0].Error())
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	v := New()
+	v.RegisterRule("even", func(fl *FieldLevel) bool {
+		return fl.Field.Int()%2 == 0
+	}, map[string]string{"zh": "{field}必须是偶数"})
+
+	req := struct {
+		N int `form:"n" validate:"even"`
+	}{N: 3}
+	err := v.Struct(&req)
+	errs, ok := err.(Errors)
+	if !ok || errs.Translate("zh")["N"] != "n必须是偶数" || errs.Translate("en")["N"] != "n is invalid" {
+		t.Fatalf("unexpected result: %v", err)
+	}
+
+	if err = v.Struct(http.MethodGet); err != ErrInvalidObj {
+		t.Fatalf("want ErrInvalidObj, got %v", err)
+	}
+}
+
+func TestStruct_TagError(t *testing.T) {
+	tests := []struct {
+		obj  interface{}
+		want string
+	}{
+		{struct {
+			A string `validate:"required,unknown"`
+		}{}, `rule "unknown" on field`},
+		{&struct {
+			A string `validate:"min=abc"`
+		}{}, `rule "min=abc"`},
+		{&struct {
+			A int `validate:"eq=x"`
+		}{}, `param must be a number`},
+		{&struct {
+			A string `validate:"eqfield=B"`
+		}{}, `field "B" not found`},
+		{&struct {
+			A string `validate:"required_with=B"`
+		}{}, `field "B" not found`},
+		// 嵌套结构体的 tag 在第一次校验到时检查
+		{&struct {
+			Items []struct {
+				N int `validate:"max="`
+			}
+		}{Items: make([]struct {
+			N int `validate:"max="`
+		}, 1)}, `param must be a number`},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 2; i++ {
+			err := New().Struct(tt.obj)
+			te, ok := err.(*TagError)
+			if !ok || !strings.Contains(te.Error(), tt.want) {
+				t.Errorf("Struct(%T) = %v, want TagError containing %s", tt.obj, err, tt.want)
+			}
+		}
+	}
+
+	// eq ne 作用于字符串时参数不必是数字
+	s := struct {
+		A string `validate:"eq=abc"`
+	}{A: "abc"}
+	if err := New().Struct(&s); err != nil {
+		t.Errorf("eq on string: %v", err)
+	}
+
+	// 注册规则后重新检查
+	v := New()
+	obj := &struct {
+		A string `validate:"later"`
+	}{}
+	if _, ok := v.Struct(obj).(*TagError); !ok {
+		t.Fatal("want TagError before the rule is registered")
+	}
+	v.RegisterRule("later", func(fl *FieldLevel) bool { return true })
+	if err := v.Struct(obj); err != nil {
+		t.Errorf("after RegisterRule: %v", err)
+	}
+}
+
+func TestIsIDCard(t *testing.T) {
+	for s, want := range map[string]bool{
+		"11010519491231002X": true,
+		"11010519491231002x": true,
+		"110105194912310021": false,
+		"110105194913310028": false,
+		"1101051949123100":   false,
+	} {
+		if IsIDCard(s) != want {
+			t.Errorf("IsIDCard(%s) != %v", s, want)
+		}
+	}
+}
+
+// TestMessageLangFallback zh-CN zh_cn 使用 zh 的消息，没有的语言使用 en
+func TestMessageLangFallback(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"zh", "age格式不正确"},
+		{"zh-CN", "age格式不正确"},
+		{"zh_cn", "age格式不正确"},
+		{"en-US", "age is invalid"},
+		{"fr", "age is invalid"},
+	}
+	for _, tt := range tests {
+		if got := Default.Message(tt.lang, "default", "age"); got != tt.want {
+			t.Errorf("Message(%q) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package gow
+
+import (
+	"github.com/gkzy/gow/websocket"
+)
+
+// Upgrade 把当前请求升级为 WebSocket 连接
+//	失败时已向客户端返回错误响应，并停止执行后续的 handler
+//		r.GET("/ws", func(c *gow.Context) {
+//			conn, err := c.Upgrade(websocket.Options{AllowedOrigins: []string{"https://example.com"}})
+//			if err != nil {
+//				return
+//			}
+//			defer conn.Close()
+//			...
+//		})
+func (c *Context) Upgrade(opts ...websocket.Options) (*websocket.Conn, error) {
+	conn, err := websocket.Upgrade(c.Writer, c.Req, opts...)
+	if err != nil {
+		c.StopRun()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,125 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"github.com/gkzy/gow/lib/logy"
+	"github.com/gkzy/gow/lib/redis"
+	"sync"
+	"time"
+)
+
+// Broker 在多个实例的 Hub 之间传递消息
+//	Subscribe 只调用一次，收到消息时调用 fn，不能阻塞
+type Broker interface {
+	Publish(data []byte) error
+	Subscribe(fn func(data []byte)) error
+	Close() error
+}
+
+// RedisBroker 使用 redis PUBLISH/SUBSCRIBE 的 Broker
+//	需要先调用 redis.InitRDSClient
+type RedisBroker struct {
+	channel string
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewRedisBroker return a redis broker
+func NewRedisBroker(channel string) *RedisBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RedisBroker{
+		channel: channel,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Publish Publish
+func (m *RedisBroker) Publish(data []byte) error {
+	_, err := redis.GetRDSCommon().Publish(m.channel, data)
+	return err
+}
+
+// Subscribe 订阅成功后在后台接收消息
+//	第一次连接或订阅失败时返回错误；之后连接断开时记录日志并每秒重试，直到 Close
+func (m *RedisBroker) Subscribe(fn func(data []byte)) error {
+	rc := redis.GetRDSCommon()
+	ready := make(chan struct{})
+	var once sync.Once
+	errc := make(chan error, 1)
+	go func() {
+		for {
+			err := rc.SubscribeNotify(m.ctx, func(channel string, data []byte) {
+				fn(data)
+			}, func() {
+				once.Do(func() { close(ready) })
+			}, m.channel)
+			select {
+			case <-ready:
+			default:
+				// 没有订阅成功过，由 Subscribe 返回错误
+				errc <- err
+				return
+			}
+			select {
+			case <-m.ctx.Done():
+				return
+			default:
+			}
+			logy.Error(fmt.Sprintf("[websocket] redis subscribe %s: %v, retry in 1s", m.channel, err))
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+
+	select {
+	case <-ready:
+		return nil
+	case err := <-errc:
+		return err
+	}
+}
+
+// Close Close
+func (m *RedisBroker) Close() error {
+	m.cancel()
+	return nil
+}
+
+// MemoryBroker 进程内的 Broker，用于测试或同一进程中的多个 Hub
+type MemoryBroker struct {
+	mu   sync.RWMutex
+	subs []func(data []byte)
+}
+
+// NewMemoryBroker return a memory broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Publish Publish
+func (m *MemoryBroker) Publish(data []byte) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, fn := range m.subs {
+		fn(data)
+	}
+	return nil
+}
+
+// Subscribe Subscribe
+func (m *MemoryBroker) Subscribe(fn func(data []byte)) error {
+	m.mu.Lock()
+	m.subs = append(m.subs, fn)
+	m.mu.Unlock()
+	return nil
+}
+
+// Close Close
+func (m *MemoryBroker) Close() error {
+	return nil
+}
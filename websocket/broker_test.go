@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/gkzy/gow/lib/redis"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedis 只支持 SELECT 和 SUBSCRIBE 的 redis，每个连接订阅后发送一条消息然后断开
+type fakeRedis struct {
+	ln         net.Listener
+	subscribed chan string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{ln: ln, subscribed: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(br)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "SELECT":
+			conn.Write([]byte("+OK\r\n"))
+		case "SUBSCRIBE":
+			ch := args[1]
+			fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(ch), ch)
+			fmt.Fprintf(conn, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$5\r\nhello\r\n", len(ch), ch)
+			s.subscribed <- ch
+			time.Sleep(50 * time.Millisecond)
+			return
+		default:
+			conn.Write([]byte("-ERR unknown command\r\n"))
+		}
+	}
+}
+
+// readCommand 读取 RESP 数组格式的命令
+func readCommand(br *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(br, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(br, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err := br.Read(b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func TestRedisBroker_Subscribe(t *testing.T) {
+	// 连接失败时 Subscribe 返回错误
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	redis.InitRDSClient(&redis.RDSConfig{Host: "127.0.0.1", Port: port})
+	b := NewRedisBroker("gow:ws")
+	if err := b.Subscribe(func([]byte) {}); err == nil {
+		t.Fatal("Subscribe should return the connect error")
+	}
+	b.Close()
+
+	// 订阅成功后返回 nil，连接断开后重新订阅
+	s := newFakeRedis(t)
+	defer s.ln.Close()
+	redis.InitRDSClient(&redis.RDSConfig{Host: "127.0.0.1", Port: s.ln.Addr().(*net.TCPAddr).Port})
+	received := make(chan string, 10)
+	b = NewRedisBroker("gow:ws")
+	defer b.Close()
+	if err := b.Subscribe(func(data []byte) { received <- string(data) }); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case ch := <-s.subscribed:
+			if ch != "gow:ws" {
+				t.Fatalf("subscribed %q", ch)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("subscription %d not received", i+1)
+		}
+		select {
+		case data := <-received:
+			if data != "hello" {
+				t.Fatalf("received %q", data)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("message %d not received", i+1)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Package websocket WebSocket 连接和 Hub
+//	基于 github.com/gorilla/websocket (RFC 6455)，增加了心跳、发送队列、消息大小限制和 origin 校验
+//		r.GET("/ws", func(c *gow.Context) {
+//			conn, err := c.Upgrade()
+//			if err != nil {
+//				return
+//			}
+//			defer conn.Close()
+//			hub.Register(conn, c.GetString("uid"))
+//			for {
+//				_, data, err := conn.ReadMessage()
+//				if err != nil {
+//					return
+//				}
+//				hub.BroadcastRoom("chat", websocket.TextMessage, data)
+//			}
+//		})
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	gws "github.com/gorilla/websocket"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 消息类型
+const (
+	TextMessage   = gws.TextMessage
+	BinaryMessage = gws.BinaryMessage
+)
+
+// 关闭码
+const (
+	CloseNormalClosure   = gws.CloseNormalClosure
+	CloseGoingAway       = gws.CloseGoingAway
+	ClosePolicyViolation = gws.ClosePolicyViolation
+	CloseMessageTooBig   = gws.CloseMessageTooBig
+	CloseInternalErr     = gws.CloseInternalServerErr
+)
+
+var (
+	// ErrClosed 连接已关闭
+	ErrClosed = errors.New("websocket: connection closed")
+	// ErrSendQueueFull 发送队列已满，客户端接收太慢，连接会被关闭
+	ErrSendQueueFull = errors.New("websocket: send queue is full")
+)
+
+// Options WebSocket 选项
+type Options struct {
+	ReadBufferSize    int
+	WriteBufferSize   int
+	ReadLimit         int64         //单个消息的最大字节数，默认为 64KB，超过时关闭连接
+	WriteWait         time.Duration //写入超时，默认为 10s
+	PongWait          time.Duration //等待 pong 的时间，默认为 60s
+	PingPeriod        time.Duration //发送 ping 的间隔，默认为 PongWait 的 9/10
+	CloseGracePeriod  time.Duration //发送 close 后等待客户端 close 的时间，默认为 1s
+	SendQueueSize     int           //发送队列的长度，默认为 256
+	Subprotocols      []string
+	EnableCompression bool
+
+	// AllowedOrigins 允许的 Origin，如 https://example.com 或 *
+	//	为空且 CheckOrigin 为 nil 时，只允许同源
+	AllowedOrigins []string
+	CheckOrigin    func(r *http.Request) bool
+}
+
+// DefaultOptions 默认选项
+func DefaultOptions() Options {
+	return Options{
+		ReadBufferSize:   4096,
+		WriteBufferSize:  4096,
+		ReadLimit:        64 << 10,
+		WriteWait:        10 * time.Second,
+		PongWait:         60 * time.Second,
+		CloseGracePeriod: time.Second,
+		SendQueueSize:    256,
+	}
+}
+
+// prepare 设置默认值
+func (o Options) prepare() Options {
+	def := DefaultOptions()
+	if o.ReadBufferSize <= 0 {
+		o.ReadBufferSize = def.ReadBufferSize
+	}
+	if o.WriteBufferSize <= 0 {
+		o.WriteBufferSize = def.WriteBufferSize
+	}
+	if o.ReadLimit <= 0 {
+		o.ReadLimit = def.ReadLimit
+	}
+	if o.WriteWait <= 0 {
+		o.WriteWait = def.WriteWait
+	}
+	if o.PongWait <= 0 {
+		o.PongWait = def.PongWait
+	}
+	if o.PingPeriod <= 0 || o.PingPeriod >= o.PongWait {
+		o.PingPeriod = o.PongWait * 9 / 10
+	}
+	if o.CloseGracePeriod <= 0 {
+		o.CloseGracePeriod = def.CloseGracePeriod
+	}
+	if o.SendQueueSize <= 0 {
+		o.SendQueueSize = def.SendQueueSize
+	}
+	return o
+}
+
+// checkOrigin 校验 Origin
+func (o Options) checkOrigin(r *http.Request) bool {
+	if o.CheckOrigin != nil {
+		return o.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(o.AllowedOrigins) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	for _, allowed := range o.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// message 发送队列中的消息
+type message struct {
+	msgType int
+	data    []byte
+}
+
+// Conn WebSocket 连接
+//	ReadMessage 只能在一个 goroutine 中调用；Send 系列方法可以并发调用
+type Conn struct {
+	Request *http.Request
+
+	id        string
+	conn      *gws.Conn
+	opts      Options
+	send      chan message
+	done      chan struct{}
+	closeOnce sync.Once
+	closeMsg  []byte
+
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+// Upgrade 把 http 请求升级为 WebSocket 连接
+//	失败时已向客户端返回错误响应
+func Upgrade(w http.ResponseWriter, r *http.Request, opts ...Options) (*Conn, error) {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt = opt.prepare()
+	upgrader := gws.Upgrader{
+		ReadBufferSize:    opt.ReadBufferSize,
+		WriteBufferSize:   opt.WriteBufferSize,
+		Subprotocols:      opt.Subprotocols,
+		EnableCompression: opt.EnableCompression,
+		CheckOrigin:       opt.checkOrigin,
+	}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &Conn{
+		Request: r,
+		id:      newID(),
+		conn:    ws,
+		opts:    opt,
+		send:    make(chan message, opt.SendQueueSize),
+		done:    make(chan struct{}),
+	}
+	ws.SetReadLimit(opt.ReadLimit)
+	ws.SetReadDeadline(time.Now().Add(opt.PongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(opt.PongWait))
+	})
+	go conn.writeLoop()
+	return conn, nil
+}
+
+// ID 连接的唯一 id
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// Subprotocol 协商的子协议
+func (c *Conn) Subprotocol() string {
+	return c.conn.Subprotocol()
+}
+
+// Set 保存连接相关的值，如 userID
+func (c *Conn) Set(key string, v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]interface{})
+	}
+	c.values[key] = v
+}
+
+// Get 读取 Set 保存的值
+func (c *Conn) Get(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.values[key]
+}
+
+// ReadMessage 读取一个消息
+//	收到 close、超过 ReadLimit 或心跳超时时返回错误，连接已关闭
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	msgType, data, err := c.conn.ReadMessage()
+	if err != nil {
+		c.closeNow()
+		return 0, nil, err
+	}
+	return msgType, data, nil
+}
+
+// ReadJSON 读取一个 json 消息
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Send 把消息放入发送队列
+//	队列已满时关闭连接，返回 ErrSendQueueFull
+func (c *Conn) Send(msgType int, data []byte) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	select {
+	case c.send <- message{msgType: msgType, data: data}:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	default:
+		c.CloseWithReason(ClosePolicyViolation, "send queue is full")
+		return ErrSendQueueFull
+	}
+}
+
+// SendText 发送文本消息
+func (c *Conn) SendText(text string) error {
+	return c.Send(TextMessage, []byte(text))
+}
+
+// SendJSON 发送 json 消息
+func (c *Conn) SendJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Send(TextMessage, data)
+}
+
+// Done 连接关闭时 close
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close 使用 1000 关闭连接
+func (c *Conn) Close() error {
+	return c.CloseWithReason(CloseNormalClosure, "")
+}
+
+// CloseWithReason 发送完队列中的消息后发送 close 帧
+//	等待客户端回复 close 后关闭连接，超过 CloseGracePeriod 时直接关闭
+func (c *Conn) CloseWithReason(code int, reason string) error {
+	c.closeOnce.Do(func() {
+		c.closeMsg = gws.FormatCloseMessage(code, reason)
+		close(c.done)
+	})
+	return nil
+}
+
+// closeNow 不发送 close 帧，直接关闭
+func (c *Conn) closeNow() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	c.conn.Close()
+}
+
+// writeLoop 发送队列中的消息和 ping
+func (c *Conn) writeLoop() {
+	ticker := time.NewTicker(c.opts.PingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.opts.WriteWait))
+			if err := c.conn.WriteMessage(msg.msgType, msg.data); err != nil {
+				c.closeNow()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(gws.PingMessage, nil, time.Now().Add(c.opts.WriteWait)); err != nil {
+				c.closeNow()
+				return
+			}
+		case <-c.done:
+			if c.closeMsg != nil {
+				c.closeGracefully()
+			}
+			return
+		}
+	}
+}
+
+// closeGracefully 发送队列中剩余的消息和 close 帧
+func (c *Conn) closeGracefully() {
+	deadline := time.Now().Add(c.opts.WriteWait)
+	c.conn.SetWriteDeadline(deadline)
+	for len(c.send) > 0 {
+		msg := <-c.send
+		if err := c.conn.WriteMessage(msg.msgType, msg.data); err != nil {
+			c.conn.Close()
+			return
+		}
+	}
+	c.conn.WriteControl(gws.CloseMessage, c.closeMsg, deadline)
+	time.AfterFunc(c.opts.CloseGracePeriod, func() {
+		c.conn.Close()
+	})
+}
+
+// IsCloseError 是否为 codes 中的关闭错误，codes 为空时只判断是否为关闭错误
+func IsCloseError(err error, codes ...int) bool {
+	var ce *gws.CloseError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if ce.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// newID 随机的连接 id
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,225 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gkzy/gow/lib/logy"
+	"sync"
+)
+
+// 广播的范围
+const (
+	scopeAll  = "all"
+	scopeRoom = "room"
+	scopeUser = "user"
+)
+
+// envelope 通过 Broker 在多个实例间传递的消息
+type envelope struct {
+	Node    string `json:"node"`
+	Scope   string `json:"scope"`
+	Target  string `json:"target,omitempty"`
+	MsgType int    `json:"type"`
+	Data    []byte `json:"data"`
+}
+
+// Hub 管理连接、房间和用户
+//	一个用户可以有多个连接(多个设备或页面)
+//	设置 Broker 后，Broadcast BroadcastRoom SendUser 会同时发送到其他实例的连接
+//		hub := websocket.NewHub(websocket.NewRedisBroker("gow:ws"))
+type Hub struct {
+	mu    sync.RWMutex
+	conns map[*Conn]string
+	rooms map[string]map[*Conn]struct{}
+	users map[string]map[*Conn]struct{}
+
+	node   string
+	broker Broker
+}
+
+// NewHub return a hub
+//	broker 为空时，只在当前实例内广播；broker 订阅失败时记录日志，不会收到其他实例的消息
+func NewHub(broker ...Broker) *Hub {
+	h := &Hub{
+		conns: make(map[*Conn]string),
+		rooms: make(map[string]map[*Conn]struct{}),
+		users: make(map[string]map[*Conn]struct{}),
+		node:  newID(),
+	}
+	if len(broker) > 0 && broker[0] != nil {
+		h.broker = broker[0]
+		if err := h.broker.Subscribe(h.receive); err != nil {
+			logy.Error(fmt.Sprintf("[websocket] broker subscribe: %v", err))
+		}
+	}
+	return h
+}
+
+// Register 注册连接，userID 可以为空
+//	连接关闭时自动注销，并离开所有房间
+func (h *Hub) Register(conn *Conn, userID string) {
+	h.mu.Lock()
+	h.conns[conn] = userID
+	if userID != "" {
+		add(h.users, userID, conn)
+	}
+	h.mu.Unlock()
+
+	go func() {
+		<-conn.Done()
+		h.Unregister(conn)
+	}()
+}
+
+// Unregister 注销连接
+func (h *Hub) Unregister(conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	userID, ok := h.conns[conn]
+	if !ok {
+		return
+	}
+	delete(h.conns, conn)
+	if userID != "" {
+		remove(h.users, userID, conn)
+	}
+	for room := range h.rooms {
+		remove(h.rooms, room, conn)
+	}
+}
+
+// Join 加入房间，连接需要先 Register
+func (h *Hub) Join(room string, conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[conn]; ok {
+		add(h.rooms, room, conn)
+	}
+}
+
+// Leave 离开房间
+func (h *Hub) Leave(room string, conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	remove(h.rooms, room, conn)
+}
+
+// Count 当前实例的连接数
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// RoomCount 当前实例中房间的连接数
+func (h *Hub) RoomCount(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Online 用户在当前实例中是否有连接
+func (h *Hub) Online(userID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.users[userID]) > 0
+}
+
+// Broadcast 发送给所有连接
+func (h *Hub) Broadcast(msgType int, data []byte) error {
+	return h.dispatch(&envelope{Scope: scopeAll, MsgType: msgType, Data: data})
+}
+
+// BroadcastRoom 发送给房间中的所有连接
+func (h *Hub) BroadcastRoom(room string, msgType int, data []byte) error {
+	return h.dispatch(&envelope{Scope: scopeRoom, Target: room, MsgType: msgType, Data: data})
+}
+
+// SendUser 发送给用户的所有连接
+func (h *Hub) SendUser(userID string, msgType int, data []byte) error {
+	return h.dispatch(&envelope{Scope: scopeUser, Target: userID, MsgType: msgType, Data: data})
+}
+
+// Close 关闭所有连接和 Broker
+func (h *Hub) Close() error {
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+	for _, conn := range conns {
+		conn.CloseWithReason(CloseGoingAway, "server shutdown")
+	}
+	if h.broker != nil {
+		return h.broker.Close()
+	}
+	return nil
+}
+
+// dispatch 发送给当前实例的连接，并通过 Broker 发送给其他实例
+func (h *Hub) dispatch(env *envelope) error {
+	h.deliver(env)
+	if h.broker == nil {
+		return nil
+	}
+	env.Node = h.node
+	b, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return h.broker.Publish(b)
+}
+
+// receive 处理 Broker 收到的消息，忽略自己发送的消息
+func (h *Hub) receive(b []byte) {
+	env := new(envelope)
+	if err := json.Unmarshal(b, env); err != nil || env.Node == h.node {
+		return
+	}
+	h.deliver(env)
+}
+
+// deliver 发送给当前实例的连接
+func (h *Hub) deliver(env *envelope) {
+	h.mu.RLock()
+	var targets []*Conn
+	switch env.Scope {
+	case scopeAll:
+		targets = make([]*Conn, 0, len(h.conns))
+		for conn := range h.conns {
+			targets = append(targets, conn)
+		}
+	case scopeRoom:
+		targets = collect(h.rooms[env.Target])
+	case scopeUser:
+		targets = collect(h.users[env.Target])
+	}
+	h.mu.RUnlock()
+
+	for _, conn := range targets {
+		conn.Send(env.MsgType, env.Data)
+	}
+}
+
+func add(m map[string]map[*Conn]struct{}, key string, conn *Conn) {
+	if m[key] == nil {
+		m[key] = make(map[*Conn]struct{})
+	}
+	m[key][conn] = struct{}{}
+}
+
+func remove(m map[string]map[*Conn]struct{}, key string, conn *Conn) {
+	delete(m[key], conn)
+	if len(m[key]) == 0 {
+		delete(m, key)
+	}
+}
+
+func collect(set map[*Conn]struct{}) []*Conn {
+	ret := make([]*Conn, 0, len(set))
+	for conn := range set {
+		ret = append(ret, conn)
+	}
+	return ret
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	gws "github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newServer(t *testing.T, hub *Hub) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, Options{ReadLimit: 16})
+		if err != nil {
+			return
+		}
+		hub.Register(conn, r.URL.Query().Get("uid"))
+		if room := r.URL.Query().Get("room"); room != "" {
+			hub.Join(room, conn)
+		}
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.Send(TextMessage, data)
+		}
+	}))
+}
+
+func dial(t *testing.T, srv *httptest.Server, query string) *gws.Conn {
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/?" + query
+	ws, _, err := gws.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func readText(t *testing.T, ws *gws.Conn) string {
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func waitCount(t *testing.T, hub *Hub, n int) {
+	for i := 0; i < 100; i++ {
+		if hub.Count() == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("want %d conns, got %d", n, hub.Count())
+}
+
+func TestHub_Fanout(t *testing.T) {
+	broker := NewMemoryBroker()
+	hub1, hub2 := NewHub(broker), NewHub(broker)
+	srv1, srv2 := newServer(t, hub1), newServer(t, hub2)
+	defer srv1.Close()
+	defer srv2.Close()
+
+	a := dial(t, srv1, "uid=1&room=chat")
+	b := dial(t, srv2, "uid=1")
+	c := dial(t, srv2, "uid=2&room=chat")
+	defer a.Close()
+	defer b.Close()
+	defer c.Close()
+	waitCount(t, hub1, 1)
+	waitCount(t, hub2, 2)
+
+	hub1.BroadcastRoom("chat", TextMessage, []byte("room"))
+	if got := readText(t, a); got != "room" {
+		t.Fatalf("a got %q", got)
+	}
+	if got := readText(t, c); got != "room" {
+		t.Fatalf("c got %q", got)
+	}
+
+	hub2.SendUser("1", TextMessage, []byte("user"))
+	if got := readText(t, a); got != "user" {
+		t.Fatalf("a got %q", got)
+	}
+	if got := readText(t, b); got != "user" {
+		t.Fatalf("b got %q", got)
+	}
+
+	a.WriteMessage(gws.TextMessage, []byte("echo"))
+	if got := readText(t, a); got != "echo" {
+		t.Fatalf("a got %q", got)
+	}
+
+	a.Close()
+	waitCount(t, hub1, 0)
+	if hub1.Online("1") || hub1.RoomCount("chat") != 0 {
+		t.Fatal("conn should be unregistered")
+	}
+}
+
+func TestConn_ReadLimit(t *testing.T) {
+	hub := NewHub()
+	srv := newServer(t, hub)
+	defer srv.Close()
+
+	ws := dial(t, srv, "")
+	defer ws.Close()
+	waitCount(t, hub, 1)
+	ws.WriteMessage(gws.TextMessage, []byte(strings.Repeat("x", 32)))
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := ws.ReadMessage()
+	if !IsCloseError(err, CloseMessageTooBig) {
+		t.Fatalf("want close 1009, got %v", err)
+	}
+	waitCount(t, hub, 0)
+}
+
+func TestUpgrade_Origin(t *testing.T) {
+	srv := newServer(t, NewHub())
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	header := http.Header{"Origin": {"http://evil.example.com"}}
+	_, resp, err := gws.DefaultDialer.Dial(url, header)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("cross origin should be rejected, err=%v", err)
+	}
+}